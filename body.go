@@ -0,0 +1,205 @@
+package main
+
+import (
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// The markers around the part of the release pull request body that is written by hand and kept on updates.
+const (
+	manualSectionStart = "<!-- git-pr-release:manual:start -->"
+	manualSectionEnd   = "<!-- git-pr-release:manual:end -->"
+)
+
+// The values of --update-strategy.
+const (
+	updateStrategyOverwrite       = "overwrite"
+	updateStrategyAppendOnly      = "append-only"
+	updateStrategySkipIfUnchanged = "skip-if-unchanged"
+)
+
+// What run did to the release pull request, reported as Result.Action.
+const (
+	actionCreated     = "created"
+	actionOverwritten = "overwritten"
+	actionAppended    = "appended"
+	actionUnchanged   = "unchanged"
+)
+
+// The start of the footer that RenderTemplate appends unless it is disabled.
+const generatedByMessageStart = "\n---\n*Automatically generated by "
+
+// A task list item, e.g. "- [x] #123 Add a feature". The groups are the text before the state, the state and the rest.
+var taskListItemPattern = regexp.MustCompile(`^(\s*[-*+]\s+\[)([ xX])(\].*)$`)
+
+var pullRequestReferencePattern = regexp.MustCompile(`#(\d+)\b`)
+
+// taskListItemNumber returns the state and the first pull request number of a task list item line.
+func taskListItemNumber(line string) (checked bool, number int, ok bool) {
+	match := taskListItemPattern.FindStringSubmatch(line)
+	if match == nil {
+		return false, 0, false
+	}
+
+	reference := pullRequestReferencePattern.FindStringSubmatch(match[3])
+	if reference == nil {
+		return false, 0, false
+	}
+
+	number, err := strconv.Atoi(reference[1])
+	if err != nil {
+		return false, 0, false
+	}
+
+	return match[2] != " ", number, true
+}
+
+// preserveCheckedItems copies the state of the task list items of existingBody to the items of body
+// that refer to the same pull request, so ticked items stay ticked when the body is regenerated.
+func preserveCheckedItems(existingBody, body string) string {
+	states := map[int]bool{}
+	existingLines := strings.Split(existingBody, "\n")
+	for i := 0; i < len(existingLines); i++ {
+		checked, number, ok := taskListItemNumber(existingLines[i])
+		if ok {
+			states[number] = checked
+		}
+	}
+
+	lines := strings.Split(body, "\n")
+	for i := 0; i < len(lines); i++ {
+		_, number, ok := taskListItemNumber(lines[i])
+		checked, known := states[number]
+		if !ok || !known {
+			continue
+		}
+
+		state := " "
+		if checked {
+			state = "x"
+		}
+		lines[i] = taskListItemPattern.ReplaceAllString(lines[i], "${1}"+state+"${3}")
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// extractManualSection returns the manual section of body with its markers, or "" when body has none.
+func extractManualSection(body string) string {
+	start := strings.Index(body, manualSectionStart)
+	if start < 0 {
+		return ""
+	}
+
+	end := strings.Index(body[start:], manualSectionEnd)
+	if end < 0 {
+		return ""
+	}
+
+	return body[start : start+end+len(manualSectionEnd)]
+}
+
+// emptyManualSection is the manual section placed by templates when the release pull request has none yet.
+func emptyManualSection() string {
+	return manualSectionStart + "\n\n" + manualSectionEnd
+}
+
+// restoreManualSection adds section to body when the template did not place it,
+// so hand-written notes are not lost. It goes right before the generated by message.
+func restoreManualSection(body, section string) string {
+	if section == "" || strings.Contains(body, manualSectionStart) {
+		return body
+	}
+
+	footer := strings.LastIndex(body, generatedByMessageStart)
+	if footer < 0 {
+		return strings.TrimSuffix(body, "\n") + "\n\n" + section + "\n"
+	}
+
+	return body[:footer] + "\n" + section + "\n" + body[footer:]
+}
+
+// applyUpdateStrategy returns the title and body to update existing with, and the action to take.
+// With actionUnchanged, the pull request must not be written.
+func applyUpdateStrategy(strategy string, existing *github.PullRequest, title, body string) (string, string, string) {
+	switch strategy {
+	case updateStrategyAppendOnly:
+		appended := appendNewItems(existing.GetBody(), body)
+		if appended == existing.GetBody() {
+			return existing.GetTitle(), appended, actionUnchanged
+		}
+		return existing.GetTitle(), appended, actionAppended
+	case updateStrategySkipIfUnchanged:
+		if title == existing.GetTitle() && withoutGeneratedByMessage(body) == withoutGeneratedByMessage(existing.GetBody()) {
+			return title, body, actionUnchanged
+		}
+	}
+	return title, body, actionOverwritten
+}
+
+// withoutGeneratedByMessage returns body without the generated by message, which links to the
+// workflow run and so changes on every run, and with the line endings a browser may have added removed.
+func withoutGeneratedByMessage(body string) string {
+	body = strings.ReplaceAll(body, "\r\n", "\n")
+	footer := strings.LastIndex(body, generatedByMessageStart)
+	if footer >= 0 {
+		body = body[:footer]
+	}
+	return body
+}
+
+// appendNewItems adds the lines of body that refer to a pull request existingBody does not mention yet
+// after the last line of existingBody that refers to a pull request. The manual section is not searched.
+func appendNewItems(existingBody, body string) string {
+	manualSection := extractManualSection(existingBody)
+	searched := strings.Replace(existingBody, manualSection, "", 1)
+
+	known := map[string]bool{}
+	matches := pullRequestReferencePattern.FindAllStringSubmatch(searched, -1)
+	for i := 0; i < len(matches); i++ {
+		known[matches[i][1]] = true
+	}
+
+	newLines := []string{}
+	lines := strings.Split(withoutGeneratedByMessage(body), "\n")
+	for i := 0; i < len(lines); i++ {
+		reference := pullRequestReferencePattern.FindStringSubmatch(lines[i])
+		if reference != nil && !known[reference[1]] && !strings.Contains(manualSection, lines[i]) {
+			newLines = append(newLines, lines[i])
+			known[reference[1]] = true
+		}
+	}
+
+	if len(newLines) == 0 {
+		return existingBody
+	}
+
+	existingLines := strings.Split(existingBody, "\n")
+	insertAt := -1
+	inManualSection := false
+	for i := 0; i < len(existingLines); i++ {
+		if strings.Contains(existingLines[i], manualSectionStart) {
+			inManualSection = true
+		}
+		if !inManualSection && pullRequestReferencePattern.MatchString(existingLines[i]) {
+			insertAt = i + 1
+		}
+		if strings.Contains(existingLines[i], manualSectionEnd) {
+			inManualSection = false
+		}
+	}
+
+	if insertAt < 0 {
+		footer := strings.LastIndex(existingBody, generatedByMessageStart)
+		if footer < 0 {
+			footer = len(existingBody)
+		}
+		return strings.TrimSuffix(existingBody[:footer], "\n") + "\n" + strings.Join(newLines, "\n") + "\n" + existingBody[footer:]
+	}
+
+	return strings.Join(slices.Insert(existingLines, insertAt, newLines...), "\n")
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-github/v60/github"
+)
+
+func TestPreserveCheckedItems(t *testing.T) {
+	tests := []struct {
+		name         string
+		existingBody string
+		body         string
+		want         string
+	}{
+		{
+			name:         "checked items stay checked",
+			existingBody: "# PRs\n- [x] #1 first\n- [ ] #2 second\n",
+			body:         "# PRs\n- [ ] #1 first\n- [ ] #2 second\n- [ ] #3 third\n",
+			want:         "# PRs\n- [x] #1 first\n- [ ] #2 second\n- [ ] #3 third\n",
+		},
+		{
+			name:         "unchecked items stay unchecked",
+			existingBody: "- [ ] #1\n",
+			body:         "- [x] #1\n- [x] #2\n",
+			want:         "- [ ] #1\n- [x] #2\n",
+		},
+		{
+			name:         "items are matched by number",
+			existingBody: "* [X] #12 renamed title @alice\n",
+			body:         "- [ ] #1 first\n- [ ] #12 new title @alice\n",
+			want:         "- [ ] #1 first\n- [x] #12 new title @alice\n",
+		},
+		{
+			name:         "edited in the browser",
+			existingBody: "- [x] #1\r\n- [ ] #2\r\n",
+			body:         "- [ ] #1\n- [ ] #2\n",
+			want:         "- [x] #1\n- [ ] #2\n",
+		},
+		{
+			name:         "lines without a task list item are untouched",
+			existingBody: "- [x] #1\n",
+			body:         "Release #1\n- #1\n  - [ ] #1\n",
+			want:         "Release #1\n- #1\n  - [x] #1\n",
+		},
+		{
+			name:         "no existing body",
+			existingBody: "",
+			body:         "- [ ] #1\n",
+			want:         "- [ ] #1\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := preserveCheckedItems(tt.existingBody, tt.body)
+			if got != tt.want {
+				t.Errorf("preserveCheckedItems returned %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractManualSection(t *testing.T) {
+	section := manualSectionStart + "\nRollback: revert #12\n" + manualSectionEnd
+
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{name: "section", body: "# PRs\n- #1\n" + section + "\n", want: section},
+		{name: "no section", body: "# PRs\n- #1\n", want: ""},
+		{name: "no end marker", body: manualSectionStart + "\nnotes\n", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := extractManualSection(tt.body)
+			if got != tt.want {
+				t.Errorf("extractManualSection returned %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRestoreManualSection(t *testing.T) {
+	section := manualSectionStart + "\nnotes\n" + manualSectionEnd
+	footer := generatedByMessageStart + "[git-pr-release-go](https://github.com/odanado/git-pr-release-go).*\n"
+
+	tests := []struct {
+		name    string
+		body    string
+		section string
+		want    string
+	}{
+		{
+			name:    "placed by the template",
+			body:    "# PRs\n" + section + "\n- #1\n",
+			section: section,
+			want:    "# PRs\n" + section + "\n- #1\n",
+		},
+		{
+			name:    "not placed by the template",
+			body:    "# PRs\n- #1\n",
+			section: section,
+			want:    "# PRs\n- #1\n\n" + section + "\n",
+		},
+		{
+			name:    "before the generated by message",
+			body:    "# PRs\n- #1\n" + footer,
+			section: section,
+			want:    "# PRs\n- #1\n\n" + section + "\n" + footer,
+		},
+		{
+			name:    "no section",
+			body:    "# PRs\n- #1\n",
+			section: "",
+			want:    "# PRs\n- #1\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := restoreManualSection(tt.body, tt.section)
+			if got != tt.want {
+				t.Errorf("restoreManualSection returned %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyUpdateStrategy(t *testing.T) {
+	footer := generatedByMessageStart + "[git-pr-release-go](https://github.com/odanado/git-pr-release-go) within [GitHub Actions workflow](https://github.com/owner/repo/actions/runs/%s/attempts/1).*\n"
+	existing := &github.PullRequest{
+		Title: github.String("Release 2021-01-01"),
+		Body:  github.String("# PRs\r\n- [x] #1\r\n- [ ] #2\r\n" + fmt.Sprintf(footer, "1")),
+	}
+
+	tests := []struct {
+		name      string
+		strategy  string
+		title     string
+		body      string
+		wantTitle string
+		wantBody  string
+		want      string
+	}{
+		{
+			name:      "overwrite",
+			strategy:  updateStrategyOverwrite,
+			title:     "Release 2021-01-02",
+			body:      "# PRs\n- [x] #1\n- [ ] #2\n",
+			wantTitle: "Release 2021-01-02",
+			wantBody:  "# PRs\n- [x] #1\n- [ ] #2\n",
+			want:      actionOverwritten,
+		},
+		{
+			name:      "skip if unchanged",
+			strategy:  updateStrategySkipIfUnchanged,
+			title:     "Release 2021-01-01",
+			body:      "# PRs\n- [x] #1\n- [ ] #2\n" + fmt.Sprintf(footer, "2"),
+			wantTitle: "Release 2021-01-01",
+			wantBody:  "# PRs\n- [x] #1\n- [ ] #2\n" + fmt.Sprintf(footer, "2"),
+			want:      actionUnchanged,
+		},
+		{
+			name:      "skip if unchanged with changes",
+			strategy:  updateStrategySkipIfUnchanged,
+			title:     "Release 2021-01-01",
+			body:      "# PRs\n- [x] #1\n- [ ] #2\n- [ ] #3\n" + fmt.Sprintf(footer, "2"),
+			wantTitle: "Release 2021-01-01",
+			wantBody:  "# PRs\n- [x] #1\n- [ ] #2\n- [ ] #3\n" + fmt.Sprintf(footer, "2"),
+			want:      actionOverwritten,
+		},
+		{
+			name:      "append only",
+			strategy:  updateStrategyAppendOnly,
+			title:     "Release 2021-01-02",
+			body:      "# PRs\n- [ ] #3\n- [x] #1\n- [ ] #2\n" + fmt.Sprintf(footer, "2"),
+			wantTitle: "Release 2021-01-01",
+			wantBody:  "# PRs\r\n- [x] #1\r\n- [ ] #2\r\n- [ ] #3\n" + fmt.Sprintf(footer, "1"),
+			want:      actionAppended,
+		},
+		{
+			name:      "append only without new pull requests",
+			strategy:  updateStrategyAppendOnly,
+			title:     "Release 2021-01-02",
+			body:      "# PRs\n- [ ] #1\n",
+			wantTitle: "Release 2021-01-01",
+			wantBody:  existing.GetBody(),
+			want:      actionUnchanged,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			title, body, action := applyUpdateStrategy(tt.strategy, existing, tt.title, tt.body)
+			if title != tt.wantTitle || body != tt.wantBody || action != tt.want {
+				t.Errorf("applyUpdateStrategy returned %q, %q and %v, want %q, %q and %v", title, body, action, tt.wantTitle, tt.wantBody, tt.want)
+			}
+		})
+	}
+}
+
+func TestAppendNewItems(t *testing.T) {
+	section := manualSectionStart + "\nRevert #9 if needed\n" + manualSectionEnd
+
+	tests := []struct {
+		name         string
+		existingBody string
+		body         string
+		want         string
+	}{
+		{
+			name:         "after the last pull request",
+			existingBody: "# PRs\n- #1 first\n\n# Notes\nnothing\n",
+			body:         "# PRs\n- #1 first\n- #2 second\n",
+			want:         "# PRs\n- #1 first\n- #2 second\n\n# Notes\nnothing\n",
+		},
+		{
+			name:         "manual section is not searched",
+			existingBody: "# PRs\n- #1\n" + section + "\n",
+			body:         "# PRs\n- #1\n- #9\n" + section + "\n",
+			want:         "# PRs\n- #1\n- #9\n" + section + "\n",
+		},
+		{
+			name:         "no pull requests yet",
+			existingBody: "# PRs\n",
+			body:         "# PRs\n- #1\n",
+			want:         "# PRs\n- #1\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := appendNewItems(tt.existingBody, tt.body)
+			if got != tt.want {
+				t.Errorf("appendNewItems returned %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
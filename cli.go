@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// The subcommands. create is run when no subcommand is given.
+const (
+	commandCreate  = "create"
+	commandPreview = "preview"
+	commandList    = "list"
+	commandPublish = "publish"
+	commandVersion = "version"
+)
+
+type cliCommand struct {
+	name        string
+	description string
+	run         func(Options) (*Result, error)
+}
+
+var cliCommands = []cliCommand{
+	{name: commandCreate, description: "Create or update the release pull request from --from into --to. This is the default command.", run: run},
+	{name: commandPreview, description: "Render the release pull request and print its title and body without writing to GitHub.", run: preview},
+	{name: commandList, description: "Print the pull requests that the release would include.", run: list},
+	{name: commandPublish, description: "Tag the merged release pull request and create a GitHub Release for it.", run: publish},
+	{name: commandVersion, description: "Print the version."},
+}
+
+func findCommand(name string) (cliCommand, bool) {
+	for i := 0; i < len(cliCommands); i++ {
+		if cliCommands[i].name == name {
+			return cliCommands[i], true
+		}
+	}
+	return cliCommand{}, false
+}
+
+// optionFlagNames returns the names of the flags of every command, which are the keys the config file may hold.
+func optionFlagNames() []string {
+	names := []string{}
+	for i := 0; i < len(cliCommands); i++ {
+		f, _ := newOptionFlags(cliCommands[i].name, nil)
+		f.flags.VisitAll(func(f *flag.Flag) {
+			names = append(names, f.Name)
+		})
+	}
+	return names
+}
+
+// runCLI runs the command line args and returns the exit code.
+func runCLI(args []string, stdout, stderr io.Writer, getenv func(string) string) int {
+	command, _ := findCommand(commandCreate)
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		if args[0] == "help" {
+			return runHelp(args[1:], stdout, stderr)
+		}
+
+		var ok bool
+		command, ok = findCommand(args[0])
+		if !ok {
+			fmt.Fprintf(stderr, "Error: unknown command %q\n\n", args[0])
+			printCommands(stderr)
+			return 2
+		}
+		args = args[1:]
+	}
+
+	if command.name == commandVersion {
+		if _, err := newOptionFlags(command.name, args); err != nil {
+			return flagError(command, err, stdout, stderr)
+		}
+		fmt.Fprintf(stdout, "git-pr-release-go version: %s, commit: %s, date: %s\n", version, commit, date)
+		return 0
+	}
+
+	optionsList, err := parseOptions(command.name, args, getenv)
+	if err != nil {
+		return flagError(command, err, stdout, stderr)
+	}
+
+	// With pipelines, the results are always reported per pipeline, even when only one runs.
+	usesPipelines := optionsList[0].pipeline != ""
+	results := []Result{}
+	failed := []string{}
+	for i := 0; i < len(optionsList); i++ {
+		options := optionsList[i]
+
+		if options.pipeline != "" {
+			GetLogger().Printf("Running the pipeline %s (%s -> %s).\n", options.pipeline, options.from, options.to)
+		}
+
+		result, err := command.run(options)
+
+		if err != nil {
+			if !usesPipelines {
+				fmt.Fprintln(stderr, "Error: ", err)
+				return 1
+			}
+			GetLogger().Printf("The pipeline %s failed: %v\n", options.pipeline, err)
+			failed = append(failed, options.pipeline)
+		}
+
+		if result == nil {
+			result = &Result{}
+		}
+
+		if !options.json {
+			printResult(stdout, result)
+		}
+
+		if usesPipelines {
+			result.Pipeline = options.pipeline
+			if err != nil {
+				result.Error = err.Error()
+			}
+		}
+		results = append(results, *result)
+	}
+
+	if optionsList[0].json {
+		var resultJson string
+		if usesPipelines {
+			resultJson, err = getResultsJson(results)
+		} else {
+			resultJson, err = getResultJson(results[0])
+		}
+
+		if err != nil {
+			fmt.Fprintln(stderr, "Error: ", err)
+			return 1
+		}
+
+		fmt.Fprintln(stdout, resultJson)
+	}
+
+	if len(failed) > 0 {
+		fmt.Fprintln(stderr, "Error: ", fmt.Errorf("%d of %d pipelines failed: %s", len(failed), len(optionsList), strings.Join(failed, ", ")))
+		return 1
+	}
+
+	return 0
+}
+
+// flagError reports an error parsing the flags of command. -h and --help print the usage instead.
+func flagError(command cliCommand, err error, stdout, stderr io.Writer) int {
+	if errors.Is(err, flag.ErrHelp) {
+		printUsage(stdout, command)
+		return 0
+	}
+	fmt.Fprintln(stderr, "Error: ", err)
+	fmt.Fprintf(stderr, "Run 'git-pr-release-go help %s' for usage.\n", command.name)
+	return 2
+}
+
+func runHelp(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		printCommands(stdout)
+		return 0
+	}
+
+	command, ok := findCommand(args[0])
+	if !ok {
+		fmt.Fprintf(stderr, "Error: unknown command %q\n\n", args[0])
+		printCommands(stderr)
+		return 2
+	}
+	printUsage(stdout, command)
+	return 0
+}
+
+func printCommands(w io.Writer) {
+	fmt.Fprintln(w, "Usage: git-pr-release-go [command] [options]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for i := 0; i < len(cliCommands); i++ {
+		fmt.Fprintf(w, "  %-8s %s\n", cliCommands[i].name, cliCommands[i].description)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run 'git-pr-release-go help <command>' for the options of a command.")
+}
+
+func printUsage(w io.Writer, command cliCommand) {
+	fmt.Fprintf(w, "Usage: git-pr-release-go %s [options]\n\n", command.name)
+	fmt.Fprintln(w, command.description)
+
+	f, _ := newOptionFlags(command.name, nil)
+	hasFlags := false
+	f.flags.VisitAll(func(*flag.Flag) { hasFlags = true })
+	if hasFlags {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "Options:")
+		f.flags.SetOutput(w)
+		f.flags.PrintDefaults()
+	}
+}
+
+// printResult prints what a command produced for humans: the rendered title and body of a dry run,
+// or the included and excluded pull requests of list.
+func printResult(w io.Writer, result *Result) {
+	if result.DryRun {
+		fmt.Fprintln(w, result.Title)
+		fmt.Fprintln(w, result.Body)
+	}
+	for i := 0; i < len(result.PullRequests); i++ {
+		pr := result.PullRequests[i]
+		fmt.Fprintf(w, "#%d %s (@%s)\n", pr.GetNumber(), pr.GetTitle(), pr.GetUser().GetLogin())
+	}
+	for i := 0; i < len(result.ExcludedPullRequests); i++ {
+		pr := result.ExcludedPullRequests[i]
+		fmt.Fprintf(w, "excluded: #%d %s (@%s): %s\n", pr.GetNumber(), pr.GetTitle(), pr.GetUser().GetLogin(), pr.Reason)
+	}
+}
+
+// preview renders the release pull request like create does, without writing to GitHub.
+func preview(options Options) (*Result, error) {
+	options.dryRun = true
+	return run(options)
+}
+
+// list finds the pull requests that the release would include.
+func list(options Options) (*Result, error) {
+	logger = GetLogger()
+
+	ctx := context.Background()
+
+	client := NewClient(GithubClientOptions{owner: options.owner, repo: options.repo, githubToken: options.gitHubToken, apiUrl: options.apiUrl, concurrency: options.concurrency, maxRetries: options.maxRetries})
+
+	pullRequests, err := fetchReleasePullRequests(ctx, client, options)
+	if err != nil {
+		return nil, err
+	}
+
+	pullRequests, excluded := excludeReleasePullRequests(pullRequests, options)
+
+	return &Result{PullRequests: pullRequests, ExcludedPullRequests: excluded}, nil
+}
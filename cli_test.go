@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRunCLI(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		wantCode   int
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			name:       "version",
+			args:       []string{"version"},
+			wantCode:   0,
+			wantStdout: "git-pr-release-go version: unset, commit: none, date: unknown\n",
+		},
+		{
+			name:       "help",
+			args:       []string{"help"},
+			wantCode:   0,
+			wantStdout: "  preview  Render the release pull request",
+		},
+		{
+			name:       "help for a command",
+			args:       []string{"help", "publish"},
+			wantCode:   0,
+			wantStdout: "-prerelease",
+		},
+		{
+			name:       "command help flag",
+			args:       []string{"list", "-h"},
+			wantCode:   0,
+			wantStdout: "Usage: git-pr-release-go list [options]",
+		},
+		{
+			name:       "unknown command",
+			args:       []string{"deploy"},
+			wantCode:   2,
+			wantStderr: `Error: unknown command "deploy"`,
+		},
+		{
+			name:       "flag of another command",
+			args:       []string{"list", "--labels", "release"},
+			wantCode:   2,
+			wantStderr: "Run 'git-pr-release-go help list' for usage.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := runCLI(tt.args, &stdout, &stderr, makeGetenv(map[string]string{}))
+
+			if code != tt.wantCode {
+				t.Errorf("runCLI returned %v, want %v", code, tt.wantCode)
+			}
+			if !strings.Contains(stdout.String(), tt.wantStdout) {
+				t.Errorf("runCLI printed %q, want %q", stdout.String(), tt.wantStdout)
+			}
+			if !strings.Contains(stderr.String(), tt.wantStderr) {
+				t.Errorf("runCLI printed %q to stderr, want %q", stderr.String(), tt.wantStderr)
+			}
+		})
+	}
+
+	t.Run("help lists the options of its command only", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		runCLI([]string{"help", "list"}, &stdout, &stderr, makeGetenv(map[string]string{}))
+
+		if strings.Contains(stdout.String(), "-template") {
+			t.Errorf("runCLI printed %q, want no -template option", stdout.String())
+		}
+	})
+}
+
+func TestRunCLI_list(t *testing.T) {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc(
+		"/repos/owner/repo/compare/to...from",
+		func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"commits": [{"sha": "sha1"}]}`)
+		},
+	)
+	mux.HandleFunc(
+		"/repos/owner/repo/commits/sha1/pulls",
+		func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `[{"number": 1}]`)
+		},
+	)
+	mux.HandleFunc(
+		"/repos/owner/repo/pulls/1",
+		func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"number": 1, "title": "Add a feature", "merged_at": "2021-01-01T00:00:00Z", "user": {"login": "alice"}}`)
+		},
+	)
+
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	getenv := makeGetenv(map[string]string{"GITHUB_REPOSITORY": "owner/repo", "GITHUB_TOKEN": "token", "GITHUB_API_URL": ts.URL})
+
+	t.Run("text", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		code := runCLI([]string{"list", "--from", "from", "--to", "to"}, &stdout, &stderr, getenv)
+
+		if code != 0 {
+			t.Fatalf("runCLI returned %v: %v", code, stderr.String())
+		}
+		want := "#1 Add a feature (@alice)\n"
+		if stdout.String() != want {
+			t.Errorf("runCLI printed %q, want %q", stdout.String(), want)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		code := runCLI([]string{"list", "--from", "from", "--to", "to", "--json"}, &stdout, &stderr, getenv)
+
+		if code != 0 {
+			t.Fatalf("runCLI returned %v: %v", code, stderr.String())
+		}
+		want := `{"pull_requests":[{"number":1,`
+		if !strings.HasPrefix(stdout.String(), want) {
+			t.Errorf("runCLI printed %q, want %q", stdout.String(), want)
+		}
+	})
+
+	t.Run("json with a pipeline", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		code := runCLI([]string{"list", "--pipeline", "from:to", "--json"}, &stdout, &stderr, getenv)
+
+		if code != 0 {
+			t.Fatalf("runCLI returned %v: %v", code, stderr.String())
+		}
+		want := `[{"pipeline":"from:to","pull_requests":[{"number":1,`
+		if !strings.HasPrefix(stdout.String(), want) {
+			t.Errorf("runCLI printed %q, want %q", stdout.String(), want)
+		}
+	})
+}
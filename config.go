@@ -0,0 +1,255 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const defaultConfigFile = ".git-pr-release.yml"
+
+// Flags that only make sense on the command line and cannot be set in the config file.
+var commandLineOnlyFlags = []string{"config", "pipeline"}
+
+// Config is the content of the config file.
+// Its keys are the flag names with "-" replaced by "_", e.g. custom_parameters for --custom-parameters.
+// Each entry of pipelines holds the same keys and overrides the top level ones.
+//
+//	labels: [release]
+//	pipelines:
+//	  staging:
+//	    from: main
+//	    to: staging
+//	  production:
+//	    from: staging
+//	    to: production
+type Config struct {
+	path      string
+	values    *yaml.Node
+	pipelines map[string]*yaml.Node
+	// pipelineNames keeps the pipelines in the order of the file.
+	pipelineNames []string
+}
+
+// ConfigError points at the key of the config file that holds an invalid value.
+type ConfigError struct {
+	Path string
+	Line int
+	Key  string
+	Err  error
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("%s:%d: %s: %v", e.Path, e.Line, e.Key, e.Err)
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// loadConfig reads the config file at path.
+// An empty path reads .git-pr-release.yml when it exists and returns nil otherwise.
+func loadConfig(path string) (*Config, error) {
+	if path == "" {
+		_, err := os.Stat(defaultConfigFile)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		path = defaultConfigFile
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseConfig(path, data)
+}
+
+func parseConfig(path string, data []byte) (*Config, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	config := &Config{path: path, values: &yaml.Node{Kind: yaml.MappingNode}, pipelines: map[string]*yaml.Node{}}
+	if len(document.Content) == 0 {
+		return config, nil
+	}
+
+	root := document.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, &ConfigError{Path: path, Line: root.Line, Key: "(root)", Err: errors.New("must be a mapping")}
+	}
+
+	for i := 0; i < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		if key.Value != "pipelines" {
+			config.values.Content = append(config.values.Content, key, value)
+			continue
+		}
+
+		if value.Kind != yaml.MappingNode {
+			return nil, &ConfigError{Path: path, Line: value.Line, Key: "pipelines", Err: errors.New("must be a mapping of pipeline names to settings")}
+		}
+		for j := 0; j < len(value.Content); j += 2 {
+			name, pipeline := value.Content[j], value.Content[j+1]
+			if pipeline.Kind != yaml.MappingNode {
+				return nil, &ConfigError{Path: path, Line: pipeline.Line, Key: "pipelines." + name.Value, Err: errors.New("must be a mapping")}
+			}
+			config.pipelines[name.Value] = pipeline
+			config.pipelineNames = append(config.pipelineNames, name.Value)
+		}
+	}
+
+	return config, nil
+}
+
+// apply sets every flag of flags that has not been set yet from the config file.
+// The values of the named pipeline take precedence over the top level ones.
+func (c *Config) apply(flags *flag.FlagSet, pipeline string) error {
+	if err := c.validateKeys(flags); err != nil {
+		return err
+	}
+
+	set := setFlags(flags)
+
+	if pipeline != "" {
+		values, ok := c.pipelines[pipeline]
+		if !ok {
+			return fmt.Errorf("%s: pipeline %q is not defined", c.path, pipeline)
+		}
+		if err := c.applyValues(flags, set, values, "pipelines."+pipeline+"."); err != nil {
+			return err
+		}
+	}
+
+	return c.applyValues(flags, set, c.values, "")
+}
+
+// validateKeys reports the first key of the file, in any pipeline, that does not name a flag
+// of flags or of another command.
+func (c *Config) validateKeys(flags *flag.FlagSet) error {
+	known := optionFlagNames()
+	flags.VisitAll(func(f *flag.Flag) {
+		known = append(known, f.Name)
+	})
+
+	if err := c.validateValueKeys(known, c.values, ""); err != nil {
+		return err
+	}
+	for i := 0; i < len(c.pipelineNames); i++ {
+		name := c.pipelineNames[i]
+		if err := c.validateValueKeys(known, c.pipelines[name], "pipelines."+name+"."); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Config) validateValueKeys(known []string, values *yaml.Node, keyPrefix string) error {
+	for i := 0; i < len(values.Content); i += 2 {
+		key := values.Content[i]
+		name := strings.ReplaceAll(key.Value, "_", "-")
+
+		if !slices.Contains(known, name) || slices.Contains(commandLineOnlyFlags, name) {
+			return &ConfigError{Path: c.path, Line: key.Line, Key: keyPrefix + key.Value, Err: errors.New("unknown key")}
+		}
+	}
+	return nil
+}
+
+func (c *Config) applyValues(flags *flag.FlagSet, set map[string]bool, values *yaml.Node, keyPrefix string) error {
+	for i := 0; i < len(values.Content); i += 2 {
+		key, value := values.Content[i], values.Content[i+1]
+		name := strings.ReplaceAll(key.Value, "_", "-")
+
+		// Keys of the flags of other commands are valid but do not apply.
+		if set[name] || flags.Lookup(name) == nil {
+			continue
+		}
+
+		flagValue, err := configValueToFlag(value)
+		if err == nil {
+			err = flags.Set(name, flagValue)
+		}
+		if err != nil {
+			return &ConfigError{Path: c.path, Line: value.Line, Key: keyPrefix + key.Value, Err: err}
+		}
+		set[name] = true
+	}
+
+	return nil
+}
+
+// configValueToFlag converts a config value into the string the flag would be given on the command line.
+// Lists of scalars become comma-separated lists, mappings become JSON.
+func configValueToFlag(value *yaml.Node) (string, error) {
+	switch value.Kind {
+	case yaml.ScalarNode:
+		return value.Value, nil
+	case yaml.SequenceNode:
+		items := []string{}
+		for i := 0; i < len(value.Content); i++ {
+			if value.Content[i].Kind != yaml.ScalarNode {
+				return configValueToJson(value)
+			}
+			items = append(items, value.Content[i].Value)
+		}
+		return strings.Join(items, ","), nil
+	default:
+		return configValueToJson(value)
+	}
+}
+
+func configValueToJson(value *yaml.Node) (string, error) {
+	var decoded any
+	if err := value.Decode(&decoded); err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(decoded)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// envName returns the environment variable that sets the flag with the given name,
+// e.g. GIT_PR_RELEASE_CUSTOM_PARAMETERS for --custom-parameters.
+func envName(flagName string) string {
+	return "GIT_PR_RELEASE_" + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// applyEnv sets every flag of flags that has not been set yet from its environment variable.
+func applyEnv(flags *flag.FlagSet, getenv func(string) string) error {
+	set := setFlags(flags)
+
+	var err error
+	flags.VisitAll(func(f *flag.Flag) {
+		value := getenv(envName(f.Name))
+		if err != nil || set[f.Name] || value == "" {
+			return
+		}
+		if setErr := flags.Set(f.Name, value); setErr != nil {
+			err = fmt.Errorf("%s: %w", envName(f.Name), setErr)
+		}
+	})
+
+	return err
+}
+
+func setFlags(flags *flag.FlagSet) map[string]bool {
+	set := map[string]bool{}
+	flags.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	return set
+}
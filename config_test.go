@@ -0,0 +1,316 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func makeDummyConfig(config string) string {
+	tmpFile, err := os.CreateTemp("", "git-pr-release.yml")
+	if err != nil {
+		panic(err)
+	}
+
+	filename := tmpFile.Name()
+	_, err = tmpFile.Write([]byte(config))
+	if err != nil {
+		panic(err)
+	}
+
+	return filename
+}
+
+func makeGetenv(env map[string]string) func(string) string {
+	return func(key string) string {
+		return env[key]
+	}
+}
+
+func TestParseOptions_config(t *testing.T) {
+	filename := makeDummyConfig(`
+from: main
+to: release/production
+labels: [release, production]
+template: release.mustache
+concurrency: 8
+custom_parameters:
+  foo: bar
+sections:
+  - title: Features
+    labels: [feature]
+`)
+	defer os.Remove(filename)
+
+	optionsList, err := parseOptions(commandCreate, []string{"--config", filename}, makeGetenv(map[string]string{"GITHUB_REPOSITORY": "owner/repo", "GITHUB_TOKEN": "token"}))
+
+	if err != nil {
+		t.Fatalf("parseOptions returned error: %v", err)
+	}
+	options := optionsList[0]
+
+	if options.from != "main" || options.to != "release/production" {
+		t.Errorf("parseOptions returned from %v and to %v, want %v and %v", options.from, options.to, "main", "release/production")
+	}
+	if !cmp.Equal(options.labels, []string{"release", "production"}) {
+		t.Errorf("parseOptions returned labels %v, want %v", options.labels, []string{"release", "production"})
+	}
+	if *options.template != "release.mustache" {
+		t.Errorf("parseOptions returned template %v, want %v", *options.template, "release.mustache")
+	}
+	if options.concurrency != 8 {
+		t.Errorf("parseOptions returned concurrency %v, want %v", options.concurrency, 8)
+	}
+	if !cmp.Equal(options.customParameters, map[string]any{"foo": "bar"}) {
+		t.Errorf("parseOptions returned custom parameters %v, want %v", options.customParameters, map[string]any{"foo": "bar"})
+	}
+	if !cmp.Equal(options.sections, []SectionConfig{{Title: "Features", Labels: []string{"feature"}}}) {
+		t.Errorf("parseOptions returned sections %v, want %v", options.sections, []SectionConfig{{Title: "Features", Labels: []string{"feature"}}})
+	}
+}
+
+func TestParseOptions_otherCommands(t *testing.T) {
+	filename := makeDummyConfig(`
+from: main
+to: production
+labels: [release]
+tag: v1.0.0
+exclude-labels: [skip-release]
+`)
+	defer os.Remove(filename)
+
+	getenv := makeGetenv(map[string]string{"GITHUB_REPOSITORY": "owner/repo", "GITHUB_TOKEN": "token"})
+
+	optionsList, err := parseOptions(commandList, []string{"--config", filename}, getenv)
+	if err != nil {
+		t.Fatalf("parseOptions returned error: %v", err)
+	}
+	if optionsList[0].from != "main" || optionsList[0].labels != nil {
+		t.Errorf("parseOptions returned from %v and labels %v, want %v and no labels", optionsList[0].from, optionsList[0].labels, "main")
+	}
+	if !cmp.Equal(optionsList[0].excludeLabels, []string{"skip-release"}) {
+		t.Errorf("parseOptions returned exclude labels %v, want %v", optionsList[0].excludeLabels, []string{"skip-release"})
+	}
+
+	optionsList, err = parseOptions(commandPublish, []string{"--config", filename}, getenv)
+	if err != nil {
+		t.Fatalf("parseOptions returned error: %v", err)
+	}
+	if optionsList[0].tag != "v1.0.0" {
+		t.Errorf("parseOptions returned tag %v, want %v", optionsList[0].tag, "v1.0.0")
+	}
+}
+
+func TestParseOptions_precedence(t *testing.T) {
+	filename := makeDummyConfig(`
+from: file-from
+to: file-to
+labels: file-label
+`)
+	defer os.Remove(filename)
+
+	env := map[string]string{
+		"GITHUB_REPOSITORY":      "owner/repo",
+		"GITHUB_TOKEN":           "token",
+		"GIT_PR_RELEASE_TO":      "env-to",
+		"GIT_PR_RELEASE_LABELS":  "env-label",
+		"GIT_PR_RELEASE_CONFIG":  filename,
+		"GIT_PR_RELEASE_DRY_RUN": "true",
+	}
+	optionsList, err := parseOptions(commandCreate, []string{"--labels", "flag-label"}, makeGetenv(env))
+
+	if err != nil {
+		t.Fatalf("parseOptions returned error: %v", err)
+	}
+	options := optionsList[0]
+
+	if options.from != "file-from" {
+		t.Errorf("parseOptions returned from %v, want %v", options.from, "file-from")
+	}
+	if options.to != "env-to" {
+		t.Errorf("parseOptions returned to %v, want %v", options.to, "env-to")
+	}
+	if !cmp.Equal(options.labels, []string{"flag-label"}) {
+		t.Errorf("parseOptions returned labels %v, want %v", options.labels, []string{"flag-label"})
+	}
+	if !options.dryRun {
+		t.Errorf("parseOptions returned dry run %v, want %v", options.dryRun, true)
+	}
+}
+
+func TestParseOptions_pipelines(t *testing.T) {
+	filename := makeDummyConfig(`
+labels: [release]
+pipelines:
+  staging:
+    from: main
+    to: staging
+  production:
+    from: staging
+    to: production
+    labels: [release, production]
+`)
+	defer os.Remove(filename)
+
+	getenv := makeGetenv(map[string]string{"GITHUB_REPOSITORY": "owner/repo", "GITHUB_TOKEN": "token"})
+
+	t.Run("staging", func(t *testing.T) {
+		optionsList, err := parseOptions(commandCreate, []string{"--config", filename, "--pipeline", "staging"}, getenv)
+
+		if err != nil {
+			t.Fatalf("parseOptions returned error: %v", err)
+		}
+		if len(optionsList) != 1 {
+			t.Fatalf("parseOptions returned %v pipelines, want %v", len(optionsList), 1)
+		}
+		options := optionsList[0]
+		if options.from != "main" || options.to != "staging" {
+			t.Errorf("parseOptions returned from %v and to %v, want %v and %v", options.from, options.to, "main", "staging")
+		}
+		if !cmp.Equal(options.labels, []string{"release"}) {
+			t.Errorf("parseOptions returned labels %v, want %v", options.labels, []string{"release"})
+		}
+	})
+
+	t.Run("production", func(t *testing.T) {
+		optionsList, err := parseOptions(commandCreate, []string{"--config", filename, "--pipeline", "production"}, getenv)
+
+		if err != nil {
+			t.Fatalf("parseOptions returned error: %v", err)
+		}
+		if len(optionsList) != 1 {
+			t.Fatalf("parseOptions returned %v pipelines, want %v", len(optionsList), 1)
+		}
+		options := optionsList[0]
+		if options.from != "staging" || options.to != "production" {
+			t.Errorf("parseOptions returned from %v and to %v, want %v and %v", options.from, options.to, "staging", "production")
+		}
+		if !cmp.Equal(options.labels, []string{"release", "production"}) {
+			t.Errorf("parseOptions returned labels %v, want %v", options.labels, []string{"release", "production"})
+		}
+	})
+
+	t.Run("all pipelines", func(t *testing.T) {
+		optionsList, err := parseOptions(commandCreate, []string{"--config", filename}, getenv)
+
+		if err != nil {
+			t.Fatalf("parseOptions returned error: %v", err)
+		}
+
+		got := [][]string{}
+		for _, options := range optionsList {
+			got = append(got, []string{options.pipeline, options.from, options.to})
+		}
+		want := [][]string{{"staging", "main", "staging"}, {"production", "staging", "production"}}
+		if !cmp.Equal(got, want) {
+			t.Errorf("parseOptions returned %v, want %v", got, want)
+		}
+	})
+
+	t.Run("from and to", func(t *testing.T) {
+		optionsList, err := parseOptions(commandCreate, []string{"--config", filename, "--from", "main", "--to", "hotfix"}, getenv)
+
+		if err != nil {
+			t.Fatalf("parseOptions returned error: %v", err)
+		}
+		if len(optionsList) != 1 || optionsList[0].pipeline != "" || optionsList[0].to != "hotfix" {
+			t.Errorf("parseOptions returned %+v, want a single hotfix run", optionsList)
+		}
+	})
+
+	t.Run("pairs", func(t *testing.T) {
+		optionsList, err := parseOptions(commandCreate, []string{"--config", filename, "--pipeline", "production", "--pipeline", "main:qa"}, getenv)
+
+		if err != nil {
+			t.Fatalf("parseOptions returned error: %v", err)
+		}
+
+		got := [][]string{}
+		for _, options := range optionsList {
+			got = append(got, append([]string{options.pipeline, options.from, options.to}, options.labels...))
+		}
+		want := [][]string{{"production", "staging", "production", "release", "production"}, {"main:qa", "main", "qa", "release"}}
+		if !cmp.Equal(got, want) {
+			t.Errorf("parseOptions returned %v, want %v", got, want)
+		}
+	})
+
+	t.Run("pairs with from", func(t *testing.T) {
+		_, err := parseOptions(commandCreate, []string{"--config", filename, "--pipeline", "main:qa", "--from", "main"}, getenv)
+
+		want := "--from and --to cannot be combined with --pipeline"
+		if err == nil || err.Error() != want {
+			t.Errorf("parseOptions returned error %v, want %v", err, want)
+		}
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		_, err := parseOptions(commandCreate, []string{"--config", filename, "--pipeline", "qa"}, getenv)
+
+		want := filename + `: pipeline "qa" is not defined`
+		if err == nil || err.Error() != want {
+			t.Errorf("parseOptions returned error %v, want %v", err, want)
+		}
+	})
+}
+
+func TestConfigApply_errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   string
+		pipeline string
+		want     string
+	}{
+		{
+			name:   "unknown key",
+			config: "from: main\nreviewer: alice\n",
+			want:   "config.yml:2: reviewer: unknown key",
+		},
+		{
+			name:   "unknown key in another pipeline",
+			config: "pipelines:\n  staging:\n    from: main\n  production:\n    form: staging\n",
+			want:   "config.yml:5: pipelines.production.form: unknown key",
+		},
+		{
+			name:   "command line only key",
+			config: "pipeline: staging\n",
+			want:   "config.yml:1: pipeline: unknown key",
+		},
+		{
+			name:     "invalid value",
+			config:   "pipelines:\n  staging:\n    concurrency: many\n",
+			pipeline: "staging",
+			want:     `config.yml:3: pipelines.staging.concurrency: parse error`,
+		},
+		{
+			name:   "invalid choice",
+			config: "api: soap\n",
+			want:   "config.yml:1: api: must be one of rest, graphql",
+		},
+		{
+			name:   "invalid pipelines",
+			config: "pipelines: [staging]\n",
+			want:   "config.yml:1: pipelines: must be a mapping of pipeline names to settings",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := parseConfig("config.yml", []byte(tt.config))
+			if err == nil {
+				flags := flag.NewFlagSet("test", flag.ContinueOnError)
+				flags.String("from", "", "")
+				flags.Int("concurrency", 4, "")
+				flags.Var(newChoiceValue("rest", "rest", "graphql"), "api", "")
+				flags.String("pipeline", "", "")
+				err = config.apply(flags, tt.pipeline)
+			}
+
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("apply returned error %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
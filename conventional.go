@@ -0,0 +1,75 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// A Conventional Commits header, e.g. "feat(api)!: add an endpoint".
+// The groups are the type, the scope, the breaking change marker and the description.
+var conventionalTitlePattern = regexp.MustCompile(`^\s*([A-Za-z]+)(?:\(([^()]*)\))?(!)?:\s*(.*?)\s*$`)
+
+// A breaking change footer of a Conventional Commits body.
+var breakingChangeFooterPattern = regexp.MustCompile(`(?m)^BREAKING[ -]CHANGE:`)
+
+// ConventionalCommit is the Conventional Commits classification of a pull request title.
+type ConventionalCommit struct {
+	// Whether the title follows Conventional Commits. The other fields are empty when it does not.
+	Conventional bool   `json:"conventional"`
+	Type         string `json:"type"`
+	Scope        string `json:"scope"`
+	Breaking     bool   `json:"breaking"`
+	Description  string `json:"description"`
+}
+
+// TemplatePullRequest is a pull request of the release as passed to the template:
+// the fields of the GitHub API with the classification of its title, the issues it closes and its tracker keys.
+type TemplatePullRequest struct {
+	github.PullRequest
+	ConventionalCommit
+	// Set by --linked-issues.
+	LinkedIssues []LinkedIssue `json:"linked_issues,omitempty"`
+	// Set by --tracker-key-pattern. Always present, so templates do not fall back to the tracker_keys of the release.
+	TrackerKeys []TrackerKey `json:"tracker_keys"`
+}
+
+// parseConventionalCommit classifies a pull request by its title and body.
+// The type is lower-cased, so "Feat: ..." is a feature too.
+func parseConventionalCommit(title, body string) ConventionalCommit {
+	match := conventionalTitlePattern.FindStringSubmatch(title)
+	if match == nil {
+		return ConventionalCommit{}
+	}
+
+	return ConventionalCommit{
+		Conventional: true,
+		Type:         strings.ToLower(match[1]),
+		Scope:        strings.TrimSpace(match[2]),
+		Breaking:     match[3] != "" || breakingChangeFooterPattern.MatchString(body),
+		Description:  match[4],
+	}
+}
+
+func newTemplatePullRequests(pullRequests []github.PullRequest) []TemplatePullRequest {
+	templatePullRequests := []TemplatePullRequest{}
+	for i := 0; i < len(pullRequests); i++ {
+		templatePullRequests = append(templatePullRequests, TemplatePullRequest{
+			PullRequest:        pullRequests[i],
+			ConventionalCommit: parseConventionalCommit(pullRequests[i].GetTitle(), pullRequests[i].GetBody()),
+		})
+	}
+	return templatePullRequests
+}
+
+// filterPullRequests returns the pull requests for which keep returns true, keeping their order.
+func filterPullRequests(pullRequests []TemplatePullRequest, keep func(TemplatePullRequest) bool) []TemplatePullRequest {
+	filtered := []TemplatePullRequest{}
+	for i := 0; i < len(pullRequests); i++ {
+		if keep(pullRequests[i]) {
+			filtered = append(filtered, pullRequests[i])
+		}
+	}
+	return filtered
+}
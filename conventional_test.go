@@ -0,0 +1,88 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/google/go-github/v60/github"
+)
+
+func TestParseConventionalCommit(t *testing.T) {
+	tests := []struct {
+		name  string
+		title string
+		body  string
+		want  ConventionalCommit
+	}{
+		{
+			name:  "type",
+			title: "fix: handle empty bodies",
+			want:  ConventionalCommit{Conventional: true, Type: "fix", Description: "handle empty bodies"},
+		},
+		{
+			name:  "scope",
+			title: "Feat(api): add an endpoint",
+			want:  ConventionalCommit{Conventional: true, Type: "feat", Scope: "api", Description: "add an endpoint"},
+		},
+		{
+			name:  "breaking marker",
+			title: "refactor(db)!: drop the legacy table",
+			want:  ConventionalCommit{Conventional: true, Type: "refactor", Scope: "db", Breaking: true, Description: "drop the legacy table"},
+		},
+		{
+			name:  "breaking footer",
+			title: "feat: new config format",
+			body:  "Details.\n\nBREAKING CHANGE: the old format is not read anymore.\n",
+			want:  ConventionalCommit{Conventional: true, Type: "feat", Breaking: true, Description: "new config format"},
+		},
+		{
+			name:  "breaking footer mentioned inline",
+			title: "docs: explain releases",
+			body:  "This is not a BREAKING CHANGE: really.",
+			want:  ConventionalCommit{Conventional: true, Type: "docs", Description: "explain releases"},
+		},
+		{
+			name:  "not conventional",
+			title: "Add an endpoint",
+			want:  ConventionalCommit{},
+		},
+		{
+			name:  "merge title",
+			title: "Merge branch 'main' into feature",
+			want:  ConventionalCommit{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseConventionalCommit(tt.title, tt.body)
+			if got != tt.want {
+				t.Errorf("parseConventionalCommit returned %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderTemplateWithConventionalCommits(t *testing.T) {
+	pullRequests := newTemplatePullRequests([]github.PullRequest{
+		{Number: github.Int(1), Title: github.String("feat(api)!: add v2")},
+		{Number: github.Int(2), Title: github.String("fix: typo")},
+	})
+	data := RenderTemplateData{
+		PullRequests:    pullRequests,
+		BreakingChanges: pullRequests[:1],
+	}
+
+	filename := makeDummyTemplate("{{#pull_requests}}#{{number}} {{type}} {{scope}} {{description}}\n{{/pull_requests}}{{#breaking_changes}}! #{{number}}\n{{/breaking_changes}}")
+	defer os.Remove(filename)
+	template, err := RenderTemplate(&filename, data, true)
+
+	if err != nil {
+		t.Errorf("RenderTemplate returned error: %v", err)
+	}
+
+	want := "#1 feat api add v2\n#2 fix  typo\n! #1\n"
+	if template != want {
+		t.Errorf("RenderTemplate returned %q, want %q", template, want)
+	}
+}
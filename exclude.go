@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// pullRequestFilter holds the options that leave pull requests out of the release.
+type pullRequestFilter struct {
+	labels  []string
+	authors []string
+	title   *regexp.Regexp
+	// The base branch the pull requests must have been merged into, or "" for any.
+	base string
+}
+
+// ExcludedPullRequest is a pull request left out of the release, passed to the template
+// as excluded_pull_requests so reviewers still see it.
+type ExcludedPullRequest struct {
+	TemplatePullRequest
+	Reason string `json:"reason"`
+}
+
+func newPullRequestFilter(options Options) pullRequestFilter {
+	filter := pullRequestFilter{
+		labels:  options.excludeLabels,
+		authors: options.excludeAuthors,
+		title:   options.excludeTitlePattern,
+	}
+	if options.excludeOtherBases {
+		filter.base = options.from
+	}
+	return filter
+}
+
+// exclusionReason returns why pr is left out of the release, or "" when it is included.
+func (f pullRequestFilter) exclusionReason(pr github.PullRequest) string {
+	for i := 0; i < len(pr.Labels); i++ {
+		for j := 0; j < len(f.labels); j++ {
+			if strings.EqualFold(pr.Labels[i].GetName(), f.labels[j]) {
+				return fmt.Sprintf("label %s", pr.Labels[i].GetName())
+			}
+		}
+	}
+
+	login := pr.GetUser().GetLogin()
+	// "dependabot" also matches the app account dependabot[bot].
+	if login != "" && (matchesLogin(login, f.authors) || matchesLogin(strings.TrimSuffix(login, "[bot]"), f.authors)) {
+		return fmt.Sprintf("author %s", login)
+	}
+
+	if f.title != nil && f.title.MatchString(pr.GetTitle()) {
+		return fmt.Sprintf("title matches %s", f.title)
+	}
+
+	if f.base != "" && pr.GetBase().GetRef() != "" && pr.GetBase().GetRef() != f.base {
+		return fmt.Sprintf("merged into %s, not %s", pr.GetBase().GetRef(), f.base)
+	}
+
+	return ""
+}
+
+// excludePullRequests splits pullRequests into the ones in the release and the ones filter leaves out.
+func excludePullRequests(pullRequests []github.PullRequest, filter pullRequestFilter) ([]github.PullRequest, []ExcludedPullRequest) {
+	included := []github.PullRequest{}
+	excluded := []ExcludedPullRequest{}
+	for i := 0; i < len(pullRequests); i++ {
+		reason := filter.exclusionReason(pullRequests[i])
+		if reason == "" {
+			included = append(included, pullRequests[i])
+			continue
+		}
+		excluded = append(excluded, ExcludedPullRequest{
+			TemplatePullRequest: newTemplatePullRequests(pullRequests[i : i+1])[0],
+			Reason:              reason,
+		})
+	}
+	return included, excluded
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v60/github"
+)
+
+func TestExclusionReason(t *testing.T) {
+	filter := pullRequestFilter{
+		labels:  []string{"skip-release"},
+		authors: []string{"dependabot", "*-bot"},
+		title:   regexp.MustCompile(`^(WIP|chore\(deps\))`),
+		base:    "main",
+	}
+
+	tests := []struct {
+		name string
+		pr   github.PullRequest
+		want string
+	}{
+		{
+			name: "included",
+			pr:   github.PullRequest{Title: github.String("Add search"), User: &github.User{Login: github.String("alice")}, Base: &github.PullRequestBranch{Ref: github.String("main")}},
+			want: "",
+		},
+		{
+			name: "label",
+			pr:   github.PullRequest{Labels: []*github.Label{{Name: github.String("Skip-Release")}}},
+			want: "label Skip-Release",
+		},
+		{
+			name: "bot author",
+			pr:   github.PullRequest{User: &github.User{Login: github.String("dependabot[bot]")}},
+			want: "author dependabot[bot]",
+		},
+		{
+			name: "author pattern",
+			pr:   github.PullRequest{User: &github.User{Login: github.String("deploy-bot")}},
+			want: "author deploy-bot",
+		},
+		{
+			name: "title",
+			pr:   github.PullRequest{Title: github.String("chore(deps): bump yaml")},
+			want: `title matches ^(WIP|chore\(deps\))`,
+		},
+		{
+			name: "other base",
+			pr:   github.PullRequest{Base: &github.PullRequestBranch{Ref: github.String("feature/search")}},
+			want: "merged into feature/search, not main",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := filter.exclusionReason(tt.pr)
+			if got != tt.want {
+				t.Errorf("exclusionReason returned %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExcludePullRequests(t *testing.T) {
+	pullRequests := []github.PullRequest{
+		{Number: github.Int(1)},
+		{Number: github.Int(2), Labels: []*github.Label{{Name: github.String("skip-release")}}},
+		{Number: github.Int(3)},
+	}
+
+	included, excluded := excludePullRequests(pullRequests, pullRequestFilter{labels: []string{"skip-release"}})
+
+	numbers := []int{}
+	for i := 0; i < len(included); i++ {
+		numbers = append(numbers, included[i].GetNumber())
+	}
+	if !cmp.Equal(numbers, []int{1, 3}) {
+		t.Errorf("excludePullRequests included %v, want %v", numbers, []int{1, 3})
+	}
+	if len(excluded) != 1 || excluded[0].GetNumber() != 2 || excluded[0].Reason != "label skip-release" {
+		t.Errorf("excludePullRequests excluded %+v, want #2 for label skip-release", excluded)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// choiceValue is a flag.Value that only accepts one of choices.
+type choiceValue struct {
+	value   string
+	choices []string
+}
+
+func newChoiceValue(value string, choices ...string) *choiceValue {
+	return &choiceValue{value: value, choices: choices}
+}
+
+func (v *choiceValue) String() string {
+	if v == nil {
+		return ""
+	}
+	return v.value
+}
+
+func (v *choiceValue) Set(value string) error {
+	if !slices.Contains(v.choices, value) {
+		return fmt.Errorf("must be one of %s", strings.Join(v.choices, ", "))
+	}
+	v.value = value
+	return nil
+}
+
+// jsonValue is a flag.Value holding any JSON value.
+type jsonValue struct {
+	raw   string
+	value any
+}
+
+func newJsonValue(raw string) *jsonValue {
+	v := &jsonValue{}
+	if err := v.Set(raw); err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func (v *jsonValue) String() string {
+	if v == nil {
+		return ""
+	}
+	return v.raw
+}
+
+func (v *jsonValue) Set(raw string) error {
+	var value any
+	if err := json.Unmarshal([]byte(raw), &value); err != nil {
+		return err
+	}
+	v.raw = raw
+	v.value = value
+	return nil
+}
+
+// stringsValue is a flag.Value that collects every occurrence of a repeated flag.
+type stringsValue struct {
+	values []string
+}
+
+func (v *stringsValue) String() string {
+	if v == nil {
+		return ""
+	}
+	return strings.Join(v.values, ",")
+}
+
+func (v *stringsValue) Set(value string) error {
+	v.values = append(v.values, value)
+	return nil
+}
+
+// splitList splits a comma-separated list flag. An empty flag is an empty list.
+func splitList(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
+// sectionsValue is a flag.Value holding a JSON array of SectionConfig.
+type sectionsValue struct {
+	raw    string
+	values []SectionConfig
+}
+
+func (v *sectionsValue) String() string {
+	if v == nil {
+		return ""
+	}
+	return v.raw
+}
+
+func (v *sectionsValue) Set(raw string) error {
+	decoder := json.NewDecoder(strings.NewReader(raw))
+	decoder.DisallowUnknownFields()
+
+	var values []SectionConfig
+	if err := decoder.Decode(&values); err != nil {
+		return err
+	}
+	for i := 0; i < len(values); i++ {
+		if values[i].Title == "" {
+			return fmt.Errorf("section %d: title is required", i+1)
+		}
+	}
+
+	v.raw = raw
+	v.values = values
+	return nil
+}
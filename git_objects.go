@@ -0,0 +1,473 @@
+package main
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The object types of a pack file.
+const (
+	packObjectCommit   = 1
+	packObjectTree     = 2
+	packObjectBlob     = 3
+	packObjectTag      = 4
+	packObjectOfsDelta = 6
+	packObjectRefDelta = 7
+)
+
+var packObjectTypes = map[int]string{
+	packObjectCommit: "commit",
+	packObjectTree:   "tree",
+	packObjectBlob:   "blob",
+	packObjectTag:    "tag",
+}
+
+// errObjectNotFound is returned for objects that are neither loose nor in a pack.
+var errObjectNotFound = errors.New("object not found")
+
+// How deep alternates of alternates are followed. The same as git.
+const maxAlternateDepth = 5
+
+// How many bytes of decoded delta bases a pack keeps, so the objects of a delta chain
+// do not decode the same bases again.
+const deltaBaseCacheSize = 16 << 20
+
+// gitObjectStore reads the objects of a git directory, loose or packed, without running git.
+type gitObjectStore struct {
+	dir   string
+	packs []*gitPack
+	// The object stores of objects/info/alternates, as in clones made with --shared or --reference.
+	alternates []*gitObjectStore
+}
+
+func openGitObjectStore(objectsDir string) (*gitObjectStore, error) {
+	return openGitObjectStoreWithAlternates(objectsDir, 0)
+}
+
+func openGitObjectStoreWithAlternates(objectsDir string, depth int) (*gitObjectStore, error) {
+	store := &gitObjectStore{dir: objectsDir}
+
+	idxPaths, err := filepath.Glob(filepath.Join(objectsDir, "pack", "*.idx"))
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < len(idxPaths); i++ {
+		pack, err := openGitPack(idxPaths[i])
+		if err != nil {
+			store.Close()
+			return nil, err
+		}
+		store.packs = append(store.packs, pack)
+	}
+
+	if depth >= maxAlternateDepth {
+		return store, nil
+	}
+	alternateDirs, err := readAlternates(objectsDir)
+	if err != nil {
+		store.Close()
+		return nil, err
+	}
+	for i := 0; i < len(alternateDirs); i++ {
+		alternate, err := openGitObjectStoreWithAlternates(alternateDirs[i], depth+1)
+		if err != nil {
+			store.Close()
+			return nil, err
+		}
+		store.alternates = append(store.alternates, alternate)
+	}
+
+	return store, nil
+}
+
+// readAlternates returns the object directories listed in objects/info/alternates.
+// Relative paths are relative to objectsDir, and directories that do not exist are skipped like git does.
+func readAlternates(objectsDir string) ([]string, error) {
+	content, err := os.ReadFile(filepath.Join(objectsDir, "info", "alternates"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	dirs := []string{}
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !filepath.IsAbs(line) {
+			line = filepath.Join(objectsDir, line)
+		}
+		if _, err := os.Stat(line); err != nil {
+			continue
+		}
+		dirs = append(dirs, line)
+	}
+	return dirs, nil
+}
+
+func (s *gitObjectStore) Close() error {
+	var err error
+	for i := 0; i < len(s.packs); i++ {
+		err = errors.Join(err, s.packs[i].Close())
+	}
+	for i := 0; i < len(s.alternates); i++ {
+		err = errors.Join(err, s.alternates[i].Close())
+	}
+	return err
+}
+
+// readObject returns the type and the content of the object sha.
+func (s *gitObjectStore) readObject(sha string) (string, []byte, error) {
+	objectType, data, err := s.readLooseObject(sha)
+	if !errors.Is(err, errObjectNotFound) {
+		return objectType, data, err
+	}
+
+	id, err := hex.DecodeString(sha)
+	if err != nil || len(id) != 20 {
+		return "", nil, fmt.Errorf("invalid object name %q", sha)
+	}
+	for i := 0; i < len(s.packs); i++ {
+		offset, ok := s.packs[i].find(id)
+		if ok {
+			return s.packs[i].readObject(s, offset)
+		}
+	}
+
+	for i := 0; i < len(s.alternates); i++ {
+		objectType, data, err := s.alternates[i].readObject(sha)
+		if !errors.Is(err, errObjectNotFound) {
+			return objectType, data, err
+		}
+	}
+
+	return "", nil, fmt.Errorf("%s: %w", sha, errObjectNotFound)
+}
+
+func (s *gitObjectStore) readLooseObject(sha string) (string, []byte, error) {
+	if len(sha) != 40 {
+		return "", nil, fmt.Errorf("invalid object name %q", sha)
+	}
+
+	file, err := os.Open(filepath.Join(s.dir, sha[:2], sha[2:]))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil, errObjectNotFound
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	defer file.Close()
+
+	reader, err := zlib.NewReader(file)
+	if err != nil {
+		return "", nil, fmt.Errorf("%s: %w", sha, err)
+	}
+	defer reader.Close()
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return "", nil, fmt.Errorf("%s: %w", sha, err)
+	}
+
+	// The content starts with a "<type> <size>\x00" header.
+	header, data, ok := bytes.Cut(content, []byte{0})
+	if !ok {
+		return "", nil, fmt.Errorf("%s: invalid object header", sha)
+	}
+	objectType, size, ok := strings.Cut(string(header), " ")
+	if !ok || size != strconv.Itoa(len(data)) {
+		return "", nil, fmt.Errorf("%s: invalid object header", sha)
+	}
+
+	return objectType, data, nil
+}
+
+// gitPack is a pack file with its version 2 index.
+type gitPack struct {
+	file    *os.File
+	fanout  [256]uint32
+	ids     []byte
+	offsets []byte
+	// Offsets of 2 GiB and more, pointed to by the offsets with the high bit set.
+	largeOffsets []byte
+	// The decoded delta bases by offset, and their offsets from the oldest, which is evicted first.
+	bases     map[int64]gitPackObject
+	baseOrder []int64
+	baseBytes int
+}
+
+type gitPackObject struct {
+	objectType string
+	data       []byte
+}
+
+func openGitPack(idxPath string) (*gitPack, error) {
+	idx, err := os.ReadFile(idxPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(idx) < 8+256*4 || !bytes.Equal(idx[:4], []byte{0xff, 't', 'O', 'c'}) || binary.BigEndian.Uint32(idx[4:8]) != 2 {
+		return nil, fmt.Errorf("%s: unsupported pack index version", idxPath)
+	}
+
+	pack := &gitPack{bases: map[int64]gitPackObject{}}
+	for i := 0; i < 256; i++ {
+		pack.fanout[i] = binary.BigEndian.Uint32(idx[8+i*4:])
+	}
+
+	count := int(pack.fanout[255])
+	idsStart := 8 + 256*4
+	offsetsStart := idsStart + count*20 + count*4
+	largeOffsetsStart := offsetsStart + count*4
+	if len(idx) < largeOffsetsStart+40 {
+		return nil, fmt.Errorf("%s: truncated pack index", idxPath)
+	}
+	pack.ids = idx[idsStart : idsStart+count*20]
+	pack.offsets = idx[offsetsStart:largeOffsetsStart]
+	pack.largeOffsets = idx[largeOffsetsStart : len(idx)-40]
+
+	pack.file, err = os.Open(strings.TrimSuffix(idxPath, ".idx") + ".pack")
+	if err != nil {
+		return nil, err
+	}
+	return pack, nil
+}
+
+func (p *gitPack) Close() error {
+	return p.file.Close()
+}
+
+// find returns the offset of the object id in the pack.
+func (p *gitPack) find(id []byte) (int64, bool) {
+	start := 0
+	if id[0] > 0 {
+		start = int(p.fanout[id[0]-1])
+	}
+	end := int(p.fanout[id[0]])
+
+	i := start + sort.Search(end-start, func(i int) bool {
+		return bytes.Compare(p.ids[(start+i)*20:(start+i+1)*20], id) >= 0
+	})
+	if i >= end || !bytes.Equal(p.ids[i*20:(i+1)*20], id) {
+		return 0, false
+	}
+
+	offset := binary.BigEndian.Uint32(p.offsets[i*4:])
+	if offset&0x80000000 == 0 {
+		return int64(offset), true
+	}
+	large := int(offset&0x7fffffff) * 8
+	if large+8 > len(p.largeOffsets) {
+		return 0, false
+	}
+	return int64(binary.BigEndian.Uint64(p.largeOffsets[large:])), true
+}
+
+// readObject returns the type and the content of the object at offset, applying deltas.
+// Bases of REF_DELTA objects are read through store, as they may be in another pack.
+func (p *gitPack) readObject(store *gitObjectStore, offset int64) (string, []byte, error) {
+	reader := io.NewSectionReader(p.file, offset, 1<<62)
+	header := &byteReader{reader: reader}
+
+	c, err := header.ReadByte()
+	if err != nil {
+		return "", nil, err
+	}
+	objectType := int(c>>4) & 0x7
+	size := int64(c & 0x0f)
+	for shift := 4; c&0x80 != 0; shift += 7 {
+		if c, err = header.ReadByte(); err != nil {
+			return "", nil, err
+		}
+		size |= int64(c&0x7f) << shift
+	}
+
+	var baseType string
+	var base []byte
+	switch objectType {
+	case packObjectOfsDelta:
+		if c, err = header.ReadByte(); err != nil {
+			return "", nil, err
+		}
+		distance := int64(c & 0x7f)
+		for c&0x80 != 0 {
+			if c, err = header.ReadByte(); err != nil {
+				return "", nil, err
+			}
+			distance = (distance+1)<<7 | int64(c&0x7f)
+		}
+		baseType, base, err = p.readDeltaBase(store, offset-distance)
+	case packObjectRefDelta:
+		id := make([]byte, 20)
+		if _, err = io.ReadFull(header, id); err != nil {
+			return "", nil, err
+		}
+		baseType, base, err = store.readObject(hex.EncodeToString(id))
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	data, err := inflate(io.NewSectionReader(p.file, offset+header.read, 1<<62), size)
+	if err != nil {
+		return "", nil, fmt.Errorf("pack offset %d: %w", offset, err)
+	}
+
+	if base != nil {
+		data, err = applyDelta(base, data)
+		if err != nil {
+			return "", nil, fmt.Errorf("pack offset %d: %w", offset, err)
+		}
+		return baseType, data, nil
+	}
+
+	name, ok := packObjectTypes[objectType]
+	if !ok {
+		return "", nil, fmt.Errorf("pack offset %d: unknown object type %d", offset, objectType)
+	}
+	return name, data, nil
+}
+
+// readDeltaBase returns the object at offset like readObject, from the cache when it was decoded before.
+// Objects larger than the cache are not kept.
+func (p *gitPack) readDeltaBase(store *gitObjectStore, offset int64) (string, []byte, error) {
+	if base, ok := p.bases[offset]; ok {
+		return base.objectType, base.data, nil
+	}
+
+	objectType, data, err := p.readObject(store, offset)
+	if err != nil || len(data) > deltaBaseCacheSize {
+		return objectType, data, err
+	}
+
+	for p.baseBytes+len(data) > deltaBaseCacheSize {
+		p.baseBytes -= len(p.bases[p.baseOrder[0]].data)
+		delete(p.bases, p.baseOrder[0])
+		p.baseOrder = p.baseOrder[1:]
+	}
+	p.bases[offset] = gitPackObject{objectType: objectType, data: data}
+	p.baseOrder = append(p.baseOrder, offset)
+	p.baseBytes += len(data)
+
+	return objectType, data, nil
+}
+
+// byteReader counts the bytes read from reader one at a time.
+type byteReader struct {
+	reader io.Reader
+	read   int64
+}
+
+func (r *byteReader) Read(b []byte) (int, error) {
+	n, err := r.reader.Read(b)
+	r.read += int64(n)
+	return n, err
+}
+
+func (r *byteReader) ReadByte() (byte, error) {
+	b := make([]byte, 1)
+	_, err := io.ReadFull(r, b)
+	return b[0], err
+}
+
+func inflate(reader io.Reader, size int64) ([]byte, error) {
+	zr, err := zlib.NewReader(reader)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(zr, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// applyDelta builds an object from base and a delta of a pack file.
+func applyDelta(base, delta []byte) ([]byte, error) {
+	errInvalid := errors.New("invalid delta")
+
+	readSize := func() (int, bool) {
+		size := 0
+		for shift := 0; len(delta) > 0; shift += 7 {
+			c := delta[0]
+			delta = delta[1:]
+			size |= int(c&0x7f) << shift
+			if c&0x80 == 0 {
+				return size, true
+			}
+		}
+		return 0, false
+	}
+
+	baseSize, ok := readSize()
+	if !ok || baseSize != len(base) {
+		return nil, errInvalid
+	}
+	resultSize, ok := readSize()
+	if !ok {
+		return nil, errInvalid
+	}
+
+	result := make([]byte, 0, resultSize)
+	for len(delta) > 0 {
+		op := delta[0]
+		delta = delta[1:]
+
+		if op&0x80 == 0 {
+			// Insert the next op bytes.
+			n := int(op)
+			if n == 0 || n > len(delta) {
+				return nil, errInvalid
+			}
+			result = append(result, delta[:n]...)
+			delta = delta[n:]
+			continue
+		}
+
+		// Copy from base. The bits of op tell which bytes of the offset and the size follow.
+		offset, size := 0, 0
+		for i := 0; i < 7; i++ {
+			if op&(1<<i) == 0 {
+				continue
+			}
+			if len(delta) == 0 {
+				return nil, errInvalid
+			}
+			if i < 4 {
+				offset |= int(delta[0]) << (8 * i)
+			} else {
+				size |= int(delta[0]) << (8 * (i - 4))
+			}
+			delta = delta[1:]
+		}
+		if size == 0 {
+			size = 0x10000
+		}
+		if offset+size > len(base) {
+			return nil, errInvalid
+		}
+		result = append(result, base[offset:offset+size]...)
+	}
+
+	if len(result) != resultSize {
+		return nil, errInvalid
+	}
+	return result, nil
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const defaultGitDir = ".git"
+
+// gitRemote is a GitHub repository that a git remote points to.
+type gitRemote struct {
+	host  string
+	owner string
+	repo  string
+}
+
+// apiUrl returns the REST API URL of the host of the remote, or nil for github.com.
+func (r *gitRemote) apiUrl() *url.URL {
+	if r.host == "github.com" {
+		return nil
+	}
+	return &url.URL{Scheme: "https", Host: r.host, Path: "/api/v3/"}
+}
+
+// readGitRemote returns the GitHub repository of the remote named name in the config of gitDir.
+func readGitRemote(gitDir, name string) (*gitRemote, error) {
+	configPath, err := gitConfigPath(gitDir)
+	if err != nil {
+		return nil, err
+	}
+
+	rawUrl, err := readGitRemoteUrl(configPath, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseGitRemoteUrl(rawUrl)
+}
+
+func gitConfigPath(gitDir string) (string, error) {
+	commonDir, err := resolveGitDir(gitDir)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(commonDir, "config"), nil
+}
+
+// resolveGitDir returns the git directory that holds the config, the refs and the objects of gitDir.
+// gitDir may be a .git file pointing to the git directory, as in worktrees and submodules.
+func resolveGitDir(gitDir string) (string, error) {
+	info, err := os.Stat(gitDir)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return gitDir, nil
+	}
+
+	data, err := os.ReadFile(gitDir)
+	if err != nil {
+		return "", err
+	}
+	target, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir:")
+	if !ok {
+		return "", fmt.Errorf("%s is not a git directory", gitDir)
+	}
+	target = strings.TrimSpace(target)
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(gitDir), target)
+	}
+
+	// Worktrees share the config, the branches and the objects of the main git directory.
+	commonDir, err := os.ReadFile(filepath.Join(target, "commondir"))
+	if err == nil {
+		common := strings.TrimSpace(string(commonDir))
+		if !filepath.IsAbs(common) {
+			common = filepath.Join(target, common)
+		}
+		return common, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return "", err
+	}
+	return target, nil
+}
+
+// readGitRemoteUrl returns the url of the remote named name in the git config file at path.
+func readGitRemoteUrl(path, name string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	section := ""
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			section = strings.TrimSpace(strings.Trim(line, "[]"))
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if ok && section == `remote "`+name+`"` && strings.EqualFold(strings.TrimSpace(key), "url") {
+			return strings.Trim(strings.TrimSpace(value), `"`), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+
+	return "", fmt.Errorf("%s has no url for the remote %q", path, name)
+}
+
+// parseGitRemoteUrl parses a remote URL in the SSH (git@github.com:owner/repo.git),
+// ssh://, https:// or git:// forms.
+func parseGitRemoteUrl(rawUrl string) (*gitRemote, error) {
+	host, path := "", ""
+	if strings.Contains(rawUrl, "://") {
+		u, err := url.Parse(rawUrl)
+		if err != nil {
+			return nil, err
+		}
+		host, path = u.Hostname(), u.Path
+	} else {
+		// The scp-like syntax of ssh, e.g. git@github.com:owner/repo.git.
+		userHost, rest, ok := strings.Cut(rawUrl, ":")
+		if !ok {
+			return nil, fmt.Errorf("%q is not a remote URL", rawUrl)
+		}
+		_, host, ok = strings.Cut(userHost, "@")
+		if !ok {
+			host = userHost
+		}
+		path = rest
+	}
+
+	owner, repo, ok := strings.Cut(strings.Trim(path, "/"), "/")
+	repo = strings.TrimSuffix(repo, ".git")
+	if !ok || owner == "" || repo == "" || strings.Contains(repo, "/") {
+		return nil, fmt.Errorf("%q does not point to a repository as owner/name", rawUrl)
+	}
+
+	host = strings.ToLower(host)
+	if host == "ssh.github.com" || host == "www.github.com" {
+		host = "github.com"
+	}
+
+	return &gitRemote{host: host, owner: owner, repo: repo}, nil
+}
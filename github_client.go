@@ -1,12 +1,16 @@
 package main
 
 import (
+	"container/heap"
 	"context"
+	"fmt"
+	"net/http"
 	"net/url"
 	"slices"
 	"strings"
 
 	"github.com/google/go-github/v60/github"
+	"golang.org/x/sync/errgroup"
 )
 
 type GithubClientOptions struct {
@@ -14,17 +18,27 @@ type GithubClientOptions struct {
 	repo        string
 	githubToken string
 	apiUrl      *url.URL
+	// concurrency limits the number of API calls made at the same time. Values below 1 mean 1.
+	concurrency int
+	// maxRetries is the number of times a rate limited or failed API call is retried.
+	maxRetries int
 }
 
 type GithubClient struct {
 	client *github.Client
 
-	owner string
-	repo  string
+	owner       string
+	repo        string
+	concurrency int
 }
 
 func NewClient(options GithubClientOptions) *GithubClient {
-	githubClient := github.NewClient(nil).WithAuthToken(options.githubToken)
+	transport := newRetryTransport(http.DefaultTransport, options.maxRetries)
+	// A retry of a request that creates a pull request, a tag or a release after a server error
+	// fails as already existing when the first one was processed.
+	transport.idempotentOnly = true
+	httpClient := &http.Client{Transport: transport}
+	githubClient := github.NewClient(httpClient).WithAuthToken(options.githubToken)
 	if options.apiUrl != nil {
 		if !strings.HasSuffix(options.apiUrl.Path, "/") {
 			options.apiUrl.Path += "/"
@@ -33,27 +47,34 @@ func NewClient(options GithubClientOptions) *GithubClient {
 	}
 
 	return &GithubClient{
-		client: githubClient,
-		owner:  options.owner,
-		repo:   options.repo,
+		client:      githubClient,
+		owner:       options.owner,
+		repo:        options.repo,
+		concurrency: max(options.concurrency, 1),
 	}
 }
 
 func (c *GithubClient) FetchPullRequestNumbers(ctx context.Context, from string, to string) ([]int, error) {
-	commitsComparison, _, err := c.client.Repositories.CompareCommits(ctx, c.owner, c.repo, to, from, nil)
+	commits, err := c.fetchComparedCommits(ctx, from, to)
 
 	if err != nil {
 		return nil, err
 	}
 
-	prNumbers := []int{}
-	for i := 0; i < len(commitsComparison.Commits); i++ {
-		commit := commitsComparison.Commits[i]
+	pullsByCommit := make([][]*github.PullRequest, len(commits))
+	err = c.forEach(ctx, len(commits), func(ctx context.Context, i int) error {
+		pulls, _, err := c.client.PullRequests.ListPullRequestsWithCommit(ctx, c.owner, c.repo, commits[i].GetSHA(), nil)
+		pullsByCommit[i] = pulls
+		return err
+	})
 
-		pulls, _, err := c.client.PullRequests.ListPullRequestsWithCommit(ctx, c.owner, c.repo, commit.GetSHA(), nil)
-		if err != nil {
-			return nil, err
-		}
+	if err != nil {
+		return nil, err
+	}
+
+	prNumbers := []int{}
+	for i := 0; i < len(pullsByCommit); i++ {
+		pulls := pullsByCommit[i]
 
 		for j := 0; j < len(pulls); j++ {
 			prNumbers = append(prNumbers, pulls[j].GetNumber())
@@ -65,29 +86,236 @@ func (c *GithubClient) FetchPullRequestNumbers(ctx context.Context, from string,
 	return slices.Compact(prNumbers), nil
 }
 
-func (c *GithubClient) FetchPullRequests(ctx context.Context, prNumbers []int) ([]github.PullRequest, error) {
-	pullRequests := []github.PullRequest{}
+// fetchComparedCommits returns the commits that are in from but not in to.
+// Every page of the comparison is followed. When the compare endpoint still
+// reports more commits than it returned, the histories of from and to are
+// walked instead.
+func (c *GithubClient) fetchComparedCommits(ctx context.Context, from string, to string) ([]*github.RepositoryCommit, error) {
+	opts := &github.ListOptions{PerPage: 100}
+	commits := []*github.RepositoryCommit{}
+	var commitsComparison *github.CommitsComparison
 
-	for i := 0; i < len(prNumbers); i++ {
-		prNumber := prNumbers[i]
-		pr, _, err := c.client.PullRequests.Get(ctx, c.owner, c.repo, prNumber)
+	for {
+		comparison, resp, err := c.client.Repositories.CompareCommits(ctx, c.owner, c.repo, to, from, opts)
 		if err != nil {
 			return nil, err
 		}
 
-		if pr.MergedAt != nil {
-			pullRequests = append(pullRequests, *pr)
+		if commitsComparison == nil {
+			commitsComparison = comparison
 		}
+		commits = append(commits, comparison.Commits...)
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	if commitsComparison.GetTotalCommits() > len(commits) {
+		GetLogger().Printf("The comparison was truncated (%d of %d commits). Walking the commit history instead.\n", len(commits), commitsComparison.GetTotalCommits())
+		return c.walkCommits(ctx, from, to, commitsComparison.GetMergeBaseCommit().GetSHA())
+	}
+
+	return commits, nil
+}
+
+// walkCommits returns the commits that are reachable from from but not from to, like commitsBetween does
+// for a local repository. The histories of both are walked from the most recent commit, read from their
+// commit lists 100 commits per call, and the walk stops once only commits reachable from to are left,
+// at the latest when it has passed mergeBase. Commits of from older than mergeBase, like the ones of a long-lived branch
+// merged into from, are found too, since the walk follows parents instead of dates.
+func (c *GithubClient) walkCommits(ctx context.Context, from string, to string, mergeBase string) ([]*github.RepositoryCommit, error) {
+	const (
+		reachableFromFrom uint8 = 1 << iota
+		reachableFromTo
+	)
+
+	fromHistory := &commitHistory{client: c, ref: from}
+	toHistory := &commitHistory{client: c, ref: to}
+	commits := map[string]*github.RepositoryCommit{}
+	flags := map[string]uint8{}
+	// The flags each commit had when its parents were last given them.
+	walked := map[string]uint8{}
+	queue := &commitQueue{}
+
+	mark := func(sha string, flag uint8) error {
+		if flags[sha]&flag == flag {
+			return nil
+		}
+		flags[sha] |= flag
+
+		commit, ok := commits[sha]
+		if !ok {
+			// A commit reachable from to is in its commit list, which is usually the shorter one.
+			history := fromHistory
+			if flag&reachableFromTo != 0 {
+				history = toHistory
+			}
+			var err error
+			commit, err = history.get(ctx, sha)
+			if err != nil {
+				return err
+			}
+			commits[sha] = commit
+		}
+		heap.Push(queue, toGitCommit(commit))
+		return nil
 	}
 
-	slices.SortFunc(pullRequests, func(a, b github.PullRequest) int {
+	fromHead, err := fromHistory.head(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := mark(fromHead, reachableFromFrom); err != nil {
+		return nil, err
+	}
+	toHead, err := toHistory.head(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := mark(toHead, reachableFromTo); err != nil {
+		return nil, err
+	}
+	// Everything below the merge base is reachable from to, so the walk stops once it is passed.
+	if mergeBase != "" {
+		if err := mark(mergeBase, reachableFromTo); err != nil {
+			return nil, err
+		}
+	}
+
+	slop := commitWalkSlop
+	for queue.Len() > 0 && slop > 0 {
+		commit := heap.Pop(queue).(*gitCommit)
+		flag := flags[commit.sha]
+		if walked[commit.sha] == flag {
+			continue
+		}
+		walked[commit.sha] = flag
+
+		for i := 0; i < len(commit.parents); i++ {
+			if err := mark(commit.parents[i], flag); err != nil {
+				return nil, err
+			}
+		}
+
+		interesting := slices.ContainsFunc(*queue, func(c *gitCommit) bool {
+			return flags[c.sha]&reachableFromTo == 0
+		})
+		if interesting {
+			slop = commitWalkSlop
+		} else {
+			slop--
+		}
+	}
+
+	between := []*github.RepositoryCommit{}
+	for sha, flag := range flags {
+		if flag == reachableFromFrom {
+			between = append(between, commits[sha])
+		}
+	}
+	slices.SortFunc(between, func(a, b *github.RepositoryCommit) int {
+		return strings.Compare(a.GetSHA(), b.GetSHA())
+	})
+	return between, nil
+}
+
+// toGitCommit converts commit into the shape the commit walk of a local repository takes.
+func toGitCommit(commit *github.RepositoryCommit) *gitCommit {
+	converted := &gitCommit{sha: commit.GetSHA(), time: commit.GetCommit().GetCommitter().GetDate().Unix()}
+	for i := 0; i < len(commit.Parents); i++ {
+		converted.parents = append(converted.parents, commit.Parents[i].GetSHA())
+	}
+	return converted
+}
+
+// commitHistory reads the commit list of ref page by page, as far as the commits asked for.
+type commitHistory struct {
+	client  *GithubClient
+	ref     string
+	commits map[string]*github.RepositoryCommit
+	first   string
+	page    int
+	done    bool
+}
+
+// head returns the SHA of the commit ref points to.
+func (h *commitHistory) head(ctx context.Context) (string, error) {
+	for h.first == "" && !h.done {
+		if err := h.next(ctx); err != nil {
+			return "", err
+		}
+	}
+	if h.first == "" {
+		return "", fmt.Errorf("no commits were found in %q", h.ref)
+	}
+	return h.first, nil
+}
+
+// get returns the commit sha with its parents. Every commit reachable from ref is in its commit list.
+func (h *commitHistory) get(ctx context.Context, sha string) (*github.RepositoryCommit, error) {
+	for h.commits[sha] == nil && !h.done {
+		if err := h.next(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if h.commits[sha] == nil {
+		return nil, fmt.Errorf("commit %q was not found in the history of %q", sha, h.ref)
+	}
+	return h.commits[sha], nil
+}
+
+func (h *commitHistory) next(ctx context.Context) error {
+	opts := &github.CommitsListOptions{SHA: h.ref, ListOptions: github.ListOptions{PerPage: 100, Page: h.page}}
+	page, resp, err := h.client.client.Repositories.ListCommits(ctx, h.client.owner, h.client.repo, opts)
+	if err != nil {
+		return err
+	}
+
+	if h.commits == nil {
+		h.commits = map[string]*github.RepositoryCommit{}
+	}
+	for i := 0; i < len(page); i++ {
+		if h.first == "" {
+			h.first = page[i].GetSHA()
+		}
+		h.commits[page[i].GetSHA()] = page[i]
+	}
+
+	h.page = resp.NextPage
+	h.done = resp.NextPage == 0
+	return nil
+}
+
+func (c *GithubClient) FetchPullRequests(ctx context.Context, prNumbers []int) ([]github.PullRequest, error) {
+	prs := make([]*github.PullRequest, len(prNumbers))
+	err := c.forEach(ctx, len(prNumbers), func(ctx context.Context, i int) error {
+		pr, _, err := c.client.PullRequests.Get(ctx, c.owner, c.repo, prNumbers[i])
+		prs[i] = pr
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	pullRequests := []github.PullRequest{}
+	for i := 0; i < len(prs); i++ {
+		if prs[i].MergedAt != nil {
+			pullRequests = append(pullRequests, *prs[i])
+		}
+	}
+
+	slices.SortStableFunc(pullRequests, func(a, b github.PullRequest) int {
 		return a.MergedAt.Compare(b.MergedAt.Time)
 	})
 
 	return pullRequests, nil
 }
 
-func (c *GithubClient) CreatePullRequest(ctx context.Context, title, body, from, to string) (*github.PullRequest, bool, error) {
+// FindPullRequest returns the open pull request from from into to, or nil when there is none.
+func (c *GithubClient) FindPullRequest(ctx context.Context, from, to string) (*github.PullRequest, error) {
 	prs, _, err := c.client.PullRequests.List(ctx, c.owner, c.repo, &github.PullRequestListOptions{
 		Base:  to,
 		Head:  from,
@@ -95,11 +323,201 @@ func (c *GithubClient) CreatePullRequest(ctx context.Context, title, body, from,
 	})
 
 	if err != nil {
-		return nil, false, err
+		return nil, err
 	}
 
 	if len(prs) > 0 {
-		return prs[0], false, nil
+		return prs[0], nil
+	}
+	return nil, nil
+}
+
+// FindMergedPullRequest returns the most recently merged pull request from from into to, or nil when there is none.
+func (c *GithubClient) FindMergedPullRequest(ctx context.Context, from, to string) (*github.PullRequest, error) {
+	prs, _, err := c.client.PullRequests.List(ctx, c.owner, c.repo, &github.PullRequestListOptions{
+		Base:        to,
+		Head:        c.owner + ":" + from,
+		State:       "closed",
+		Sort:        "updated",
+		Direction:   "desc",
+		ListOptions: github.ListOptions{PerPage: 100},
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	var merged *github.PullRequest
+	for i := 0; i < len(prs); i++ {
+		if prs[i].MergedAt == nil {
+			continue
+		}
+		if merged == nil || prs[i].MergedAt.After(merged.MergedAt.Time) {
+			merged = prs[i]
+		}
+	}
+	return merged, nil
+}
+
+// CreateTag creates the lightweight tag name on sha.
+// A tag that already points to sha is left as is, so publishing can be retried.
+func (c *GithubClient) CreateTag(ctx context.Context, name, sha string) error {
+	ref, resp, err := c.client.Git.GetRef(ctx, c.owner, c.repo, "tags/"+name)
+	if err == nil {
+		if ref.GetObject().GetSHA() != sha {
+			return fmt.Errorf("tag %q already exists on %s", name, ref.GetObject().GetSHA())
+		}
+		return nil
+	}
+	if resp == nil || resp.StatusCode != http.StatusNotFound {
+		return err
+	}
+
+	_, _, err = c.client.Git.CreateRef(ctx, c.owner, c.repo, &github.Reference{
+		Ref:    github.String("refs/tags/" + name),
+		Object: &github.GitObject{SHA: github.String(sha)},
+	})
+	return err
+}
+
+func (c *GithubClient) CreateRelease(ctx context.Context, release *github.RepositoryRelease) (*github.RepositoryRelease, error) {
+	created, _, err := c.client.Repositories.CreateRelease(ctx, c.owner, c.repo, release)
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// listTags returns every tag of the repository.
+func (c *GithubClient) listTags(ctx context.Context) ([]*github.RepositoryTag, error) {
+	tags := []*github.RepositoryTag{}
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		page, resp, err := c.client.Repositories.ListTags(ctx, c.owner, c.repo, opts)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, page...)
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return tags, nil
+}
+
+// FindLatestVersionTag returns the highest release version tag, e.g. "v1.2.3", that is reachable from ref,
+// or "" when there is none. Tags on the commit excludedSha are skipped, so the version of the commit
+// being released is not its own previous version.
+func (c *GithubClient) FindLatestVersionTag(ctx context.Context, ref string, excludedSha string) (string, error) {
+	tags, err := c.listTags(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	names := []string{}
+	for i := 0; i < len(tags); i++ {
+		if excludedSha == "" || tags[i].GetCommit().GetSHA() != excludedSha {
+			names = append(names, tags[i].GetName())
+		}
+	}
+
+	versionTags := sortVersionTags(names)
+	for i := 0; i < len(versionTags); i++ {
+		comparison, _, err := c.client.Repositories.CompareCommits(ctx, c.owner, c.repo, versionTags[i], ref, &github.ListOptions{PerPage: 1})
+		if err != nil {
+			return "", err
+		}
+
+		// The tag is reachable when ref contains all of its commits.
+		status := comparison.GetStatus()
+		if status == "ahead" || status == "identical" {
+			return versionTags[i], nil
+		}
+	}
+
+	return "", nil
+}
+
+// FindVersionTagOn returns the highest release version tag on the commit sha, or "" when there is none.
+func (c *GithubClient) FindVersionTagOn(ctx context.Context, sha string) (string, error) {
+	tags, err := c.listTags(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	names := []string{}
+	for i := 0; i < len(tags); i++ {
+		if tags[i].GetCommit().GetSHA() == sha {
+			names = append(names, tags[i].GetName())
+		}
+	}
+
+	versionTags := sortVersionTags(names)
+	if len(versionTags) == 0 {
+		return "", nil
+	}
+	return versionTags[0], nil
+}
+
+// FindRelease returns the release of tag, or nil when there is none.
+// Draft releases are not found by tag, so they are looked for in the list of releases.
+func (c *GithubClient) FindRelease(ctx context.Context, tag string) (*github.RepositoryRelease, error) {
+	release, resp, err := c.client.Repositories.GetReleaseByTag(ctx, c.owner, c.repo, tag)
+	if err == nil {
+		return release, nil
+	}
+	if resp == nil || resp.StatusCode != http.StatusNotFound {
+		return nil, err
+	}
+
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		releases, resp, err := c.client.Repositories.ListReleases(ctx, c.owner, c.repo, opts)
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < len(releases); i++ {
+			if releases[i].GetTagName() == tag {
+				return releases[i], nil
+			}
+		}
+		if resp.NextPage == 0 {
+			return nil, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// FetchIssue returns the issue number of repository, given as owner/name, or nil when it does not exist,
+// cannot be read with the token or is a pull request.
+func (c *GithubClient) FetchIssue(ctx context.Context, repository string, number int) (*LinkedIssue, error) {
+	owner, repo, _ := strings.Cut(repository, "/")
+	issue, resp, err := c.client.Issues.Get(ctx, owner, repo, number)
+	if err != nil {
+		if resp != nil && (resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if issue.IsPullRequest() {
+		return nil, nil
+	}
+
+	linkedIssue := newLinkedIssue(repository, issue)
+	return &linkedIssue, nil
+}
+
+func (c *GithubClient) CreatePullRequest(ctx context.Context, title, body, from, to string) (*github.PullRequest, bool, error) {
+	existing, err := c.FindPullRequest(ctx, from, to)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	if existing != nil {
+		return existing, false, nil
 	}
 
 	pr, _, err := c.client.PullRequests.Create(ctx, c.owner, c.repo, &github.NewPullRequest{
@@ -135,3 +553,92 @@ func (c *GithubClient) AddLabelsToPullRequest(ctx context.Context, prNumber int,
 	_, _, err := c.client.Issues.AddLabelsToIssue(ctx, c.owner, c.repo, prNumber, labels)
 	return err
 }
+
+func (c *GithubClient) AddAssigneesToPullRequest(ctx context.Context, prNumber int, assignees []string) error {
+	if len(assignees) == 0 {
+		return nil
+	}
+	_, _, err := c.client.Issues.AddAssignees(ctx, c.owner, c.repo, prNumber, assignees)
+	return err
+}
+
+// PendingReviewers returns the reviewers and team reviewers that have neither been requested
+// on the pull request nor reviewed it yet.
+func (c *GithubClient) PendingReviewers(ctx context.Context, prNumber int, reviewers, teamReviewers []string) ([]string, []string, error) {
+	requested, _, err := c.client.PullRequests.ListReviewers(ctx, c.owner, c.repo, prNumber, &github.ListOptions{PerPage: 100})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reviews, _, err := c.client.PullRequests.ListReviews(ctx, c.owner, c.repo, prNumber, &github.ListOptions{PerPage: 100})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	knownUsers := []string{}
+	for i := 0; i < len(requested.Users); i++ {
+		knownUsers = append(knownUsers, requested.Users[i].GetLogin())
+	}
+	for i := 0; i < len(reviews); i++ {
+		knownUsers = append(knownUsers, reviews[i].GetUser().GetLogin())
+	}
+
+	knownTeams := []string{}
+	for i := 0; i < len(requested.Teams); i++ {
+		knownTeams = append(knownTeams, requested.Teams[i].GetSlug())
+	}
+
+	return excludeLogins(reviewers, knownUsers), excludeLogins(teamReviewers, knownTeams), nil
+}
+
+// RequestReviewers requests reviews on the pull request from the reviewers and team reviewers
+// that are still pending, see PendingReviewers. It returns the ones that were requested.
+func (c *GithubClient) RequestReviewers(ctx context.Context, prNumber int, reviewers, teamReviewers []string) ([]string, []string, error) {
+	reviewers, teamReviewers, err := c.PendingReviewers(ctx, prNumber, reviewers, teamReviewers)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(reviewers) == 0 && len(teamReviewers) == 0 {
+		return nil, nil, nil
+	}
+
+	_, _, err = c.client.PullRequests.RequestReviewers(ctx, c.owner, c.repo, prNumber, github.ReviewersRequest{
+		Reviewers:     reviewers,
+		TeamReviewers: teamReviewers,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return reviewers, teamReviewers, nil
+}
+
+// excludeLogins returns the logins that are not in excluded. GitHub logins are case-insensitive.
+func excludeLogins(logins []string, excluded []string) []string {
+	result := []string{}
+	for i := 0; i < len(logins); i++ {
+		if !slices.ContainsFunc(excluded, func(login string) bool { return strings.EqualFold(login, logins[i]) }) {
+			result = append(result, logins[i])
+		}
+	}
+	return result
+}
+
+// forEach calls fn for every index in [0, n) using at most c.concurrency goroutines.
+// The first error cancels the context passed to the remaining calls and is returned.
+func (c *GithubClient) forEach(ctx context.Context, n int, fn func(ctx context.Context, i int) error) error {
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(c.concurrency)
+
+	for i := 0; i < n; i++ {
+		g.Go(func() error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			return fn(ctx, i)
+		})
+	}
+
+	return g.Wait()
+}
@@ -2,10 +2,14 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -61,6 +65,189 @@ func TestFetchPullRequestNumbers(t *testing.T) {
 	}
 }
 
+func TestFetchPullRequestNumbers_paginated(t *testing.T) {
+	ctx := context.Background()
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc(
+		"/repos/owner/repo/compare/to...from",
+		func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Query().Get("page") {
+			case "":
+				w.Header().Set("Link", `<http://example.com/repos/owner/repo/compare/to...from?page=2>; rel="next"`)
+				fmt.Fprint(w, `{"total_commits": 3, "commits": [{"sha": "sha1"}, {"sha": "sha2"}]}`)
+			case "2":
+				fmt.Fprint(w, `{"total_commits": 3, "commits": [{"sha": "sha3"}]}`)
+			}
+		},
+	)
+	mux.HandleFunc(
+		"/repos/owner/repo/commits/sha1/pulls",
+		func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `[{"number": 1}]`)
+		},
+	)
+	mux.HandleFunc(
+		"/repos/owner/repo/commits/sha2/pulls",
+		func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `[{"number": 2}]`)
+		},
+	)
+	mux.HandleFunc(
+		"/repos/owner/repo/commits/sha3/pulls",
+		func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `[{"number": 3}]`)
+		},
+	)
+
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	apiUrl, _ := url.Parse(ts.URL)
+	client := NewClient(GithubClientOptions{owner: "owner", repo: "repo", githubToken: "githubToken", apiUrl: apiUrl})
+
+	prNumbers, err := client.FetchPullRequestNumbers(ctx, "from", "to")
+
+	if err != nil {
+		t.Errorf("FetchPullRequestNumbers returned error: %v", err)
+	}
+
+	want := []int{1, 2, 3}
+	if !cmp.Equal(prNumbers, want) {
+		t.Errorf("FetchPullRequestNumbers returned %+v, want %+v", prNumbers, want)
+	}
+}
+
+func TestFetchPullRequestNumbers_truncated(t *testing.T) {
+	// commit returns a commit of a commit list, committed on the given day of January 2021.
+	commit := func(sha string, day int, parents ...string) string {
+		parentsJson := []string{}
+		for _, parent := range parents {
+			parentsJson = append(parentsJson, fmt.Sprintf(`{"sha": %q}`, parent))
+		}
+		return fmt.Sprintf(
+			`{"sha": %q, "commit": {"committer": {"date": "2021-01-%02dT00:00:00Z"}}, "parents": [%s]}`,
+			sha, day, strings.Join(parentsJson, ", "),
+		)
+	}
+
+	tests := []struct {
+		name string
+		// The commit lists of from and to, page by page, newest first.
+		histories map[string][]string
+		want      []int
+	}{
+		{
+			name: "linear",
+			histories: map[string][]string{
+				"from": {
+					"[" + commit("sha1", 6, "sha2") + ", " + commit("sha2", 5, "sha3") + "]",
+					"[" + commit("sha3", 4, "sha4") + ", " + commit("sha4", 3, "base") + ", " + commit("base", 2, "old") + ", " + commit("old", 1) + "]",
+				},
+				"to": {
+					"[" + commit("hotfix", 7, "base") + ", " + commit("base", 2, "old") + ", " + commit("old", 1) + "]",
+				},
+			},
+			want: []int{1, 2, 3, 4},
+		},
+		{
+			// sha4 was committed on a long-lived branch before the merge base and merged into from by sha1,
+			// so it comes after the merge base in the commit list.
+			name: "merged branch older than the merge base",
+			histories: map[string][]string{
+				"from": {
+					"[" + commit("sha1", 6, "sha2", "sha4") + ", " + commit("sha2", 5, "sha3") + "]",
+					"[" + commit("sha3", 4, "base") + ", " + commit("base", 3, "old") + ", " + commit("sha4", 2, "old") + ", " + commit("old", 1) + "]",
+				},
+				"to": {
+					"[" + commit("hotfix", 7, "base") + ", " + commit("base", 3, "old") + ", " + commit("old", 1) + "]",
+				},
+			},
+			want: []int{1, 2, 3, 4},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			mux := http.NewServeMux()
+
+			mux.HandleFunc(
+				"/repos/owner/repo/compare/to...from",
+				func(w http.ResponseWriter, r *http.Request) {
+					switch r.URL.Query().Get("page") {
+					case "":
+						w.Header().Set("Link", `<http://example.com/repos/owner/repo/compare/to...from?page=2>; rel="next"`)
+						fmt.Fprint(w, `{"total_commits": 4, "merge_base_commit": {"sha": "base"}, "commits": [{"sha": "sha1"}]}`)
+					case "2":
+						fmt.Fprint(w, `{"total_commits": 4, "merge_base_commit": {"sha": "base"}, "commits": [{"sha": "sha2"}]}`)
+					}
+				},
+			)
+			mux.HandleFunc(
+				"/repos/owner/repo/compare/",
+				func(w http.ResponseWriter, r *http.Request) {
+					t.Errorf("unexpected comparison %v", r.URL.Path)
+					http.NotFound(w, r)
+				},
+			)
+			mux.HandleFunc(
+				"/repos/owner/repo/commits",
+				func(w http.ResponseWriter, r *http.Request) {
+					sha := r.URL.Query().Get("sha")
+					pages, ok := tt.histories[sha]
+					if !ok {
+						t.Errorf("Repositories.ListCommits was called with sha %v", sha)
+						http.NotFound(w, r)
+						return
+					}
+					page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+					page = max(page, 1)
+					if page < len(pages) {
+						w.Header().Set("Link", fmt.Sprintf(`<http://example.com/repos/owner/repo/commits?sha=%s&page=%d>; rel="next"`, sha, page+1))
+					}
+					fmt.Fprint(w, pages[page-1])
+				},
+			)
+			for i := 1; i <= 4; i++ {
+				mux.HandleFunc(
+					fmt.Sprintf("/repos/owner/repo/commits/sha%d/pulls", i),
+					func(w http.ResponseWriter, r *http.Request) {
+						fmt.Fprintf(w, `[{"number": %d}]`, i)
+					},
+				)
+			}
+			for _, sha := range []string{"hotfix", "base", "old"} {
+				mux.HandleFunc(
+					fmt.Sprintf("/repos/owner/repo/commits/%s/pulls", sha),
+					func(w http.ResponseWriter, r *http.Request) {
+						t.Errorf("commits reachable from to must not be looked up")
+						fmt.Fprint(w, `[]`)
+					},
+				)
+			}
+
+			ts := httptest.NewServer(mux)
+			defer ts.Close()
+
+			apiUrl, _ := url.Parse(ts.URL)
+			client := NewClient(GithubClientOptions{owner: "owner", repo: "repo", githubToken: "githubToken", apiUrl: apiUrl})
+
+			prNumbers, err := client.FetchPullRequestNumbers(ctx, "from", "to")
+
+			if err != nil {
+				t.Errorf("FetchPullRequestNumbers returned error: %v", err)
+			}
+
+			if !cmp.Equal(prNumbers, tt.want) {
+				t.Errorf("FetchPullRequestNumbers returned %+v, want %+v", prNumbers, tt.want)
+			}
+		})
+	}
+}
+
 func TestFetchPullRequests(t *testing.T) {
 	ctx := context.Background()
 
@@ -104,6 +291,204 @@ func TestFetchPullRequests(t *testing.T) {
 	}
 }
 
+// slowHandler delays every response and records the highest number of requests served at once.
+type slowHandler struct {
+	handler  http.Handler
+	delay    time.Duration
+	mu       sync.Mutex
+	inFlight int
+	peak     int
+}
+
+func (h *slowHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	h.inFlight++
+	h.peak = max(h.peak, h.inFlight)
+	h.mu.Unlock()
+
+	time.Sleep(h.delay)
+	h.handler.ServeHTTP(w, r)
+
+	h.mu.Lock()
+	h.inFlight--
+	h.mu.Unlock()
+}
+
+func TestFetchPullRequestNumbers_concurrency(t *testing.T) {
+	ctx := context.Background()
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc(
+		"/repos/owner/repo/compare/to...from",
+		func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"commits": [{"sha": "sha1"}, {"sha": "sha2"}, {"sha": "sha3"}, {"sha": "sha4"}, {"sha": "sha5"}, {"sha": "sha6"}]}`)
+		},
+	)
+	for i := 1; i <= 6; i++ {
+		mux.HandleFunc(
+			fmt.Sprintf("/repos/owner/repo/commits/sha%d/pulls", i),
+			func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintf(w, `[{"number": %d}]`, 7-i)
+			},
+		)
+	}
+
+	handler := &slowHandler{handler: mux, delay: 50 * time.Millisecond}
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+
+	apiUrl, _ := url.Parse(ts.URL)
+	client := NewClient(GithubClientOptions{owner: "owner", repo: "repo", githubToken: "githubToken", apiUrl: apiUrl, concurrency: 3})
+
+	prNumbers, err := client.FetchPullRequestNumbers(ctx, "from", "to")
+
+	if err != nil {
+		t.Errorf("FetchPullRequestNumbers returned error: %v", err)
+	}
+
+	want := []int{1, 2, 3, 4, 5, 6}
+	if !cmp.Equal(prNumbers, want) {
+		t.Errorf("FetchPullRequestNumbers returned %+v, want %+v", prNumbers, want)
+	}
+
+	if handler.peak != 3 {
+		t.Errorf("FetchPullRequestNumbers made %v requests at once, want %v", handler.peak, 3)
+	}
+}
+
+func TestFetchPullRequests_concurrency(t *testing.T) {
+	ctx := context.Background()
+
+	mux := http.NewServeMux()
+
+	for i := 1; i <= 5; i++ {
+		mux.HandleFunc(
+			fmt.Sprintf("/repos/owner/repo/pulls/%d", i),
+			func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintf(w, `{"number": %d, "merged_at": "2021-01-01T00:00:00Z"}`, i)
+			},
+		)
+	}
+
+	handler := &slowHandler{handler: mux, delay: 50 * time.Millisecond}
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+
+	apiUrl, _ := url.Parse(ts.URL)
+	client := NewClient(GithubClientOptions{owner: "owner", repo: "repo", githubToken: "githubToken", apiUrl: apiUrl, concurrency: 2})
+
+	prs, err := client.FetchPullRequests(ctx, []int{1, 2, 3, 4, 5})
+
+	if err != nil {
+		t.Errorf("FetchPullRequests returned error: %v", err)
+	}
+
+	// Every pull request has the same merged_at, so the order must follow the requested numbers.
+	got := []int{}
+	for i := 0; i < len(prs); i++ {
+		got = append(got, prs[i].GetNumber())
+	}
+	want := []int{1, 2, 3, 4, 5}
+	if !cmp.Equal(got, want) {
+		t.Errorf("FetchPullRequests returned %+v, want %+v", got, want)
+	}
+
+	if handler.peak != 2 {
+		t.Errorf("FetchPullRequests made %v requests at once, want %v", handler.peak, 2)
+	}
+}
+
+func TestFetchPullRequests_cancelOnError(t *testing.T) {
+	ctx := context.Background()
+
+	var mu sync.Mutex
+	requested := 0
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc(
+		"/repos/owner/repo/pulls/",
+		func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			requested++
+			mu.Unlock()
+
+			if r.URL.Path == "/repos/owner/repo/pulls/1" {
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprint(w, `{"message": "Not Found"}`)
+				return
+			}
+			fmt.Fprint(w, `{"number": 2, "merged_at": "2021-01-01T00:00:00Z"}`)
+		},
+	)
+
+	handler := &slowHandler{handler: mux, delay: 50 * time.Millisecond}
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+
+	apiUrl, _ := url.Parse(ts.URL)
+	client := NewClient(GithubClientOptions{owner: "owner", repo: "repo", githubToken: "githubToken", apiUrl: apiUrl, concurrency: 1})
+
+	_, err := client.FetchPullRequests(ctx, []int{1, 2, 3, 4, 5})
+
+	if err == nil {
+		t.Errorf("FetchPullRequests returned no error")
+	}
+
+	if requested != 1 {
+		t.Errorf("FetchPullRequests made %v requests after the first error, want %v", requested-1, 0)
+	}
+}
+
+func TestFindLatestVersionTag(t *testing.T) {
+	ctx := context.Background()
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc(
+		"/repos/owner/repo/tags",
+		func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Query().Get("page") {
+			case "":
+				w.Header().Set("Link", `<http://example.com/repos/owner/repo/tags?page=2>; rel="next"`)
+				fmt.Fprint(w, `[{"name": "v1.0.0"}, {"name": "nightly"}]`)
+			case "2":
+				fmt.Fprint(w, `[{"name": "v1.3.0", "commit": {"sha": "release"}}, {"name": "v1.2.0"}, {"name": "v1.1.0"}]`)
+			}
+		},
+	)
+	// v1.2.0 was tagged on a branch that is not merged into production yet.
+	mux.HandleFunc(
+		"/repos/owner/repo/compare/v1.2.0...production",
+		func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"status": "diverged"}`)
+		},
+	)
+	mux.HandleFunc(
+		"/repos/owner/repo/compare/v1.1.0...production",
+		func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"status": "ahead"}`)
+		},
+	)
+
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	apiUrl, _ := url.Parse(ts.URL)
+	client := NewClient(GithubClientOptions{owner: "owner", repo: "repo", githubToken: "githubToken", apiUrl: apiUrl})
+
+	// v1.3.0 is on the commit being released.
+	tag, err := client.FindLatestVersionTag(ctx, "production", "release")
+
+	if err != nil {
+		t.Fatalf("FindLatestVersionTag returned error: %v", err)
+	}
+	if tag != "v1.1.0" {
+		t.Errorf("FindLatestVersionTag returned %v, want %v", tag, "v1.1.0")
+	}
+}
+
 func TestCreatePullRequest(t *testing.T) {
 	ctx := context.Background()
 
@@ -203,3 +588,122 @@ func TestAddLabelsToPullRequest(t *testing.T) {
 		t.Errorf("PullRequests.Get returned error: %v", err)
 	}
 }
+
+func TestAddAssigneesToPullRequest(t *testing.T) {
+	ctx := context.Background()
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc(
+		"/repos/owner/repo/issues/1/assignees",
+		func(w http.ResponseWriter, r *http.Request) {
+			var body struct {
+				Assignees []string `json:"assignees"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+
+			want := []string{"alice", "bob"}
+			if !cmp.Equal(body.Assignees, want) {
+				t.Errorf("Issues.AddAssignees was called with %v, want %v", body.Assignees, want)
+			}
+			fmt.Fprint(w, `{"number": 1}`)
+		},
+	)
+
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	apiUrl, _ := url.Parse(ts.URL)
+	client := NewClient(GithubClientOptions{owner: "owner", repo: "repo", githubToken: "githubToken", apiUrl: apiUrl})
+
+	err := client.AddAssigneesToPullRequest(ctx, 1, []string{"alice", "bob"})
+
+	if err != nil {
+		t.Errorf("AddAssigneesToPullRequest returned error: %v", err)
+	}
+}
+
+func TestRequestReviewers(t *testing.T) {
+	ctx := context.Background()
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc(
+		"/repos/owner/repo/pulls/1/requested_reviewers",
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == "GET" {
+				fmt.Fprint(w, `{"users": [{"login": "alice"}], "teams": [{"slug": "qa"}]}`)
+			}
+			if r.Method == "POST" {
+				var body github.ReviewersRequest
+				json.NewDecoder(r.Body).Decode(&body)
+
+				want := github.ReviewersRequest{Reviewers: []string{"carol"}, TeamReviewers: []string{"sre"}}
+				if !cmp.Equal(body, want) {
+					t.Errorf("PullRequests.RequestReviewers was called with %+v, want %+v", body, want)
+				}
+				fmt.Fprint(w, `{"number": 1}`)
+			}
+		},
+	)
+	mux.HandleFunc(
+		"/repos/owner/repo/pulls/1/reviews",
+		func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `[{"user": {"login": "bob"}, "state": "APPROVED"}]`)
+		},
+	)
+
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	apiUrl, _ := url.Parse(ts.URL)
+	client := NewClient(GithubClientOptions{owner: "owner", repo: "repo", githubToken: "githubToken", apiUrl: apiUrl})
+
+	reviewers, teamReviewers, err := client.RequestReviewers(ctx, 1, []string{"Alice", "bob", "carol"}, []string{"qa", "sre"})
+
+	if err != nil {
+		t.Errorf("RequestReviewers returned error: %v", err)
+	}
+
+	if !cmp.Equal(reviewers, []string{"carol"}) || !cmp.Equal(teamReviewers, []string{"sre"}) {
+		t.Errorf("RequestReviewers returned %v and %v, want %v and %v", reviewers, teamReviewers, []string{"carol"}, []string{"sre"})
+	}
+}
+
+func TestRequestReviewers_alreadyRequested(t *testing.T) {
+	ctx := context.Background()
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc(
+		"/repos/owner/repo/pulls/1/requested_reviewers",
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != "GET" {
+				t.Errorf("reviewers that were already requested must not be requested again")
+			}
+			fmt.Fprint(w, `{"users": [{"login": "alice"}], "teams": []}`)
+		},
+	)
+	mux.HandleFunc(
+		"/repos/owner/repo/pulls/1/reviews",
+		func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `[]`)
+		},
+	)
+
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	apiUrl, _ := url.Parse(ts.URL)
+	client := NewClient(GithubClientOptions{owner: "owner", repo: "repo", githubToken: "githubToken", apiUrl: apiUrl})
+
+	reviewers, teamReviewers, err := client.RequestReviewers(ctx, 1, []string{"alice"}, nil)
+
+	if err != nil {
+		t.Errorf("RequestReviewers returned error: %v", err)
+	}
+
+	if reviewers != nil || teamReviewers != nil {
+		t.Errorf("RequestReviewers returned %v and %v, want nothing", reviewers, teamReviewers)
+	}
+}
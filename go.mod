@@ -4,10 +4,10 @@ go 1.22.1
 
 require (
 	github.com/cbroglie/mustache v1.4.0
+	github.com/google/go-cmp v0.6.0
 	github.com/google/go-github/v60 v60.0.0
+	golang.org/x/sync v0.9.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
-require (
-	github.com/google/go-cmp v0.6.0 // indirect
-	github.com/google/go-querystring v1.1.0 // indirect
-)
+require github.com/google/go-querystring v1.1.0 // indirect
@@ -0,0 +1,1011 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v60/github"
+)
+
+const releasePullRequestsQuery = `
+query($owner: String!, $repo: String!, $base: String!, $head: String!, $cursor: String) {
+  repository(owner: $owner, name: $repo) {
+    ref(qualifiedName: $base) {
+      compare(headRef: $head) {
+        commits(first: 100, after: $cursor) {
+          pageInfo {
+            hasNextPage
+            endCursor
+          }
+          nodes {
+            associatedPullRequests(first: 10) {
+              nodes {
+                id
+                databaseId
+                number
+                title
+                body
+                url
+                state
+                locked
+                activeLockReason
+                isDraft
+                merged
+                mergeable
+                maintainerCanModify
+                authorAssociation
+                mergedAt
+                createdAt
+                updatedAt
+                closedAt
+                additions
+                deletions
+                changedFiles
+                commits {
+                  totalCount
+                }
+                comments {
+                  totalCount
+                }
+                baseRefName
+                baseRefOid
+                baseRepository {
+                  ...repository
+                }
+                headRefName
+                headRefOid
+                headRepository {
+                  ...repository
+                }
+                headRepositoryOwner {
+                  ...actor
+                }
+                mergeCommit {
+                  oid
+                }
+                author {
+                  ...actor
+                }
+                mergedBy {
+                  ...actor
+                }
+                assignees(first: 100) {
+                  nodes {
+                    ...actor
+                  }
+                }
+                reviewRequests(first: 100) {
+                  nodes {
+                    requestedReviewer {
+                      __typename
+                      ...actor
+                      ... on Team {
+                        id
+                        databaseId
+                        name
+                        slug
+                        description
+                        privacy
+                        url
+                        organization {
+                          databaseId
+                        }
+                      }
+                    }
+                  }
+                }
+                milestone {
+                  id
+                  number
+                  title
+                  description
+                  state
+                  url
+                  dueOn
+                  createdAt
+                  updatedAt
+                  closedAt
+                  creator {
+                    ...actor
+                  }
+                  openIssues: issues(states: OPEN) {
+                    totalCount
+                  }
+                  closedIssues: issues(states: CLOSED) {
+                    totalCount
+                  }
+                  openPullRequests: pullRequests(states: OPEN) {
+                    totalCount
+                  }
+                  closedPullRequests: pullRequests(states: [CLOSED, MERGED]) {
+                    totalCount
+                  }
+                }
+                autoMergeRequest {
+                  enabledBy {
+                    ...actor
+                  }
+                  mergeMethod
+                  commitHeadline
+                  commitBody
+                }
+                labels(first: 100) {
+                  nodes {
+                    id
+                    name
+                    color
+                    description
+                    isDefault
+                  }
+                }
+              }
+            }
+          }
+        }
+      }
+    }
+  }
+}
+
+fragment actor on Actor {
+  __typename
+  login
+  url
+  avatarUrl
+  ... on User {
+    id
+    databaseId
+    isSiteAdmin
+  }
+  ... on Bot {
+    id
+    databaseId
+  }
+  ... on Organization {
+    id
+    databaseId
+  }
+}
+
+fragment repository on Repository {
+  id
+  databaseId
+  name
+  nameWithOwner
+  description
+  homepageUrl
+  url
+  sshUrl
+  mirrorUrl
+  isPrivate
+  isFork
+  isArchived
+  isDisabled
+  isTemplate
+  visibility
+  forkingAllowed
+  webCommitSignoffRequired
+  hasIssuesEnabled
+  hasProjectsEnabled
+  hasWikiEnabled
+  diskUsage
+  stargazerCount
+  forkCount
+  createdAt
+  updatedAt
+  pushedAt
+  primaryLanguage {
+    name
+  }
+  licenseInfo {
+    key
+    name
+    spdxId
+  }
+  repositoryTopics(first: 20) {
+    nodes {
+      topic {
+        name
+      }
+    }
+  }
+  openIssues: issues(states: OPEN) {
+    totalCount
+  }
+  openPullRequests: pullRequests(states: OPEN) {
+    totalCount
+  }
+  defaultBranchRef {
+    name
+  }
+  owner {
+    ...actor
+  }
+}`
+
+type graphqlRequest struct {
+	Query     string         `json:"query"`
+	Variables map[string]any `json:"variables"`
+}
+
+type graphqlError struct {
+	Message string `json:"message"`
+}
+
+type graphqlResponse[T any] struct {
+	Data   T              `json:"data"`
+	Errors []graphqlError `json:"errors"`
+}
+
+type graphqlPullRequest struct {
+	Id                  string        `json:"id"`
+	DatabaseId          int64         `json:"databaseId"`
+	Number              int           `json:"number"`
+	Title               string        `json:"title"`
+	Body                string        `json:"body"`
+	Url                 string        `json:"url"`
+	State               string        `json:"state"`
+	Locked              bool          `json:"locked"`
+	ActiveLockReason    string        `json:"activeLockReason"`
+	IsDraft             bool          `json:"isDraft"`
+	Merged              bool          `json:"merged"`
+	Mergeable           string        `json:"mergeable"`
+	MaintainerCanModify bool          `json:"maintainerCanModify"`
+	AuthorAssociation   string        `json:"authorAssociation"`
+	MergedAt            *time.Time    `json:"mergedAt"`
+	CreatedAt           *time.Time    `json:"createdAt"`
+	UpdatedAt           *time.Time    `json:"updatedAt"`
+	ClosedAt            *time.Time    `json:"closedAt"`
+	Additions           int           `json:"additions"`
+	Deletions           int           `json:"deletions"`
+	ChangedFiles        int           `json:"changedFiles"`
+	Commits             graphqlCount  `json:"commits"`
+	Comments            graphqlCount  `json:"comments"`
+	BaseRefName         string        `json:"baseRefName"`
+	BaseRefOid          string        `json:"baseRefOid"`
+	BaseRepository      *graphqlRepo  `json:"baseRepository"`
+	HeadRefName         string        `json:"headRefName"`
+	HeadRefOid          string        `json:"headRefOid"`
+	HeadRepository      *graphqlRepo  `json:"headRepository"`
+	HeadRepositoryOwner *graphqlActor `json:"headRepositoryOwner"`
+	MergeCommit         *struct {
+		Oid string `json:"oid"`
+	} `json:"mergeCommit"`
+	Author    *graphqlActor `json:"author"`
+	MergedBy  *graphqlActor `json:"mergedBy"`
+	Assignees struct {
+		Nodes []graphqlActor `json:"nodes"`
+	} `json:"assignees"`
+	ReviewRequests struct {
+		Nodes []struct {
+			RequestedReviewer *graphqlReviewer `json:"requestedReviewer"`
+		} `json:"nodes"`
+	} `json:"reviewRequests"`
+	Milestone        *graphqlMilestone `json:"milestone"`
+	AutoMergeRequest *struct {
+		EnabledBy      *graphqlActor `json:"enabledBy"`
+		MergeMethod    string        `json:"mergeMethod"`
+		CommitHeadline string        `json:"commitHeadline"`
+		CommitBody     string        `json:"commitBody"`
+	} `json:"autoMergeRequest"`
+	Labels graphqlLabels `json:"labels"`
+}
+
+type graphqlCount struct {
+	TotalCount int `json:"totalCount"`
+}
+
+// graphqlActor is a user, a bot or an organization, read with the actor fragment.
+type graphqlActor struct {
+	Typename    string `json:"__typename"`
+	Id          string `json:"id"`
+	DatabaseId  int64  `json:"databaseId"`
+	Login       string `json:"login"`
+	Url         string `json:"url"`
+	AvatarUrl   string `json:"avatarUrl"`
+	IsSiteAdmin bool   `json:"isSiteAdmin"`
+}
+
+// graphqlReviewer is a requested reviewer, a team when Typename is "Team" and an actor otherwise.
+type graphqlReviewer struct {
+	graphqlActor
+	Name         string  `json:"name"`
+	Slug         string  `json:"slug"`
+	Description  *string `json:"description"`
+	Privacy      string  `json:"privacy"`
+	Organization *struct {
+		DatabaseId int64 `json:"databaseId"`
+	} `json:"organization"`
+}
+
+// graphqlRepo is a repository read with the repository fragment.
+type graphqlRepo struct {
+	Id                       string     `json:"id"`
+	DatabaseId               int64      `json:"databaseId"`
+	Name                     string     `json:"name"`
+	NameWithOwner            string     `json:"nameWithOwner"`
+	Description              *string    `json:"description"`
+	HomepageUrl              *string    `json:"homepageUrl"`
+	Url                      string     `json:"url"`
+	SshUrl                   string     `json:"sshUrl"`
+	MirrorUrl                *string    `json:"mirrorUrl"`
+	IsPrivate                bool       `json:"isPrivate"`
+	IsFork                   bool       `json:"isFork"`
+	IsArchived               bool       `json:"isArchived"`
+	IsDisabled               bool       `json:"isDisabled"`
+	IsTemplate               bool       `json:"isTemplate"`
+	Visibility               string     `json:"visibility"`
+	ForkingAllowed           bool       `json:"forkingAllowed"`
+	WebCommitSignoffRequired bool       `json:"webCommitSignoffRequired"`
+	HasIssuesEnabled         bool       `json:"hasIssuesEnabled"`
+	HasProjectsEnabled       bool       `json:"hasProjectsEnabled"`
+	HasWikiEnabled           bool       `json:"hasWikiEnabled"`
+	DiskUsage                int        `json:"diskUsage"`
+	StargazerCount           int        `json:"stargazerCount"`
+	ForkCount                int        `json:"forkCount"`
+	CreatedAt                *time.Time `json:"createdAt"`
+	UpdatedAt                *time.Time `json:"updatedAt"`
+	PushedAt                 *time.Time `json:"pushedAt"`
+	PrimaryLanguage          *struct {
+		Name string `json:"name"`
+	} `json:"primaryLanguage"`
+	LicenseInfo *struct {
+		Key    string  `json:"key"`
+		Name   string  `json:"name"`
+		SpdxId *string `json:"spdxId"`
+	} `json:"licenseInfo"`
+	RepositoryTopics struct {
+		Nodes []struct {
+			Topic struct {
+				Name string `json:"name"`
+			} `json:"topic"`
+		} `json:"nodes"`
+	} `json:"repositoryTopics"`
+	OpenIssues       graphqlCount `json:"openIssues"`
+	OpenPullRequests graphqlCount `json:"openPullRequests"`
+	DefaultBranchRef *struct {
+		Name string `json:"name"`
+	} `json:"defaultBranchRef"`
+	Owner *graphqlActor `json:"owner"`
+}
+
+type graphqlMilestone struct {
+	Id                 string        `json:"id"`
+	Number             int           `json:"number"`
+	Title              string        `json:"title"`
+	Description        *string       `json:"description"`
+	State              string        `json:"state"`
+	Url                string        `json:"url"`
+	DueOn              *time.Time    `json:"dueOn"`
+	CreatedAt          *time.Time    `json:"createdAt"`
+	UpdatedAt          *time.Time    `json:"updatedAt"`
+	ClosedAt           *time.Time    `json:"closedAt"`
+	Creator            *graphqlActor `json:"creator"`
+	OpenIssues         graphqlCount  `json:"openIssues"`
+	ClosedIssues       graphqlCount  `json:"closedIssues"`
+	OpenPullRequests   graphqlCount  `json:"openPullRequests"`
+	ClosedPullRequests graphqlCount  `json:"closedPullRequests"`
+}
+
+type graphqlLabels struct {
+	Nodes []struct {
+		Id          string `json:"id"`
+		Name        string `json:"name"`
+		Color       string `json:"color"`
+		Description string `json:"description"`
+		IsDefault   *bool  `json:"isDefault"`
+	} `json:"nodes"`
+}
+
+type graphqlIssue struct {
+	Number     int    `json:"number"`
+	Title      string `json:"title"`
+	State      string `json:"state"`
+	Url        string `json:"url"`
+	Repository struct {
+		NameWithOwner string `json:"nameWithOwner"`
+	} `json:"repository"`
+	Labels graphqlLabels `json:"labels"`
+}
+
+type closingIssuesData struct {
+	// Keyed by the aliases of closingIssuesQuery.
+	Repository map[string]*struct {
+		ClosingIssuesReferences struct {
+			Nodes []graphqlIssue `json:"nodes"`
+		} `json:"closingIssuesReferences"`
+	} `json:"repository"`
+}
+
+type releasePullRequestsData struct {
+	Repository *struct {
+		Ref *struct {
+			Compare *struct {
+				Commits struct {
+					PageInfo struct {
+						HasNextPage bool   `json:"hasNextPage"`
+						EndCursor   string `json:"endCursor"`
+					} `json:"pageInfo"`
+					Nodes []struct {
+						AssociatedPullRequests struct {
+							Nodes []graphqlPullRequest `json:"nodes"`
+						} `json:"associatedPullRequests"`
+					} `json:"nodes"`
+				} `json:"commits"`
+			} `json:"compare"`
+		} `json:"ref"`
+	} `json:"repository"`
+}
+
+// graphqlUrl returns the GraphQL endpoint that belongs to the REST base URL.
+// GitHub Enterprise Server serves REST under /api/v3/ and GraphQL under /api/graphql.
+func (c *GithubClient) graphqlUrl() string {
+	baseUrl := *c.client.BaseURL
+	if strings.HasSuffix(baseUrl.Path, "/api/v3/") {
+		baseUrl.Path = strings.TrimSuffix(baseUrl.Path, "v3/") + "graphql"
+		return baseUrl.String()
+	}
+	return "graphql"
+}
+
+func graphqlQuery[T any](ctx context.Context, c *GithubClient, query string, variables map[string]any) (*T, error) {
+	req, err := c.client.NewRequest("POST", c.graphqlUrl(), graphqlRequest{Query: query, Variables: variables})
+	if err != nil {
+		return nil, err
+	}
+
+	var resp graphqlResponse[T]
+	// The queries only read, so they are retried after server errors too.
+	_, err = c.client.Do(withReadOnly(ctx), req, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp.Errors) > 0 {
+		messages := []string{}
+		for i := 0; i < len(resp.Errors); i++ {
+			messages = append(messages, resp.Errors[i].Message)
+		}
+		return nil, errors.New("graphql: " + strings.Join(messages, "; "))
+	}
+
+	return &resp.Data, nil
+}
+
+// FetchReleasePullRequestsGraphQL resolves the merged pull requests between to and from
+// with the GraphQL API. It returns the same result as FetchPullRequestNumbers followed by
+// FetchPullRequests, with one query per 100 commits instead of one REST call per commit and pull request.
+func (c *GithubClient) FetchReleasePullRequestsGraphQL(ctx context.Context, from string, to string) ([]github.PullRequest, error) {
+	variables := map[string]any{
+		"owner":  c.owner,
+		"repo":   c.repo,
+		"base":   to,
+		"head":   from,
+		"cursor": nil,
+	}
+
+	links := restLinks{api: c.client.BaseURL.String(), repository: c.owner + "/" + c.repo}
+	seen := map[int]bool{}
+	pullRequests := []github.PullRequest{}
+
+	for {
+		data, err := graphqlQuery[releasePullRequestsData](ctx, c, releasePullRequestsQuery, variables)
+		if err != nil {
+			return nil, err
+		}
+
+		if data.Repository == nil {
+			return nil, fmt.Errorf("repository %s/%s was not found", c.owner, c.repo)
+		}
+		if data.Repository.Ref == nil {
+			return nil, fmt.Errorf("ref %q was not found", to)
+		}
+		if data.Repository.Ref.Compare == nil {
+			return nil, fmt.Errorf("ref %q could not be compared with %q", from, to)
+		}
+
+		commits := data.Repository.Ref.Compare.Commits
+		for i := 0; i < len(commits.Nodes); i++ {
+			pulls := commits.Nodes[i].AssociatedPullRequests.Nodes
+			for j := 0; j < len(pulls); j++ {
+				if pulls[j].MergedAt == nil || seen[pulls[j].Number] {
+					continue
+				}
+				seen[pulls[j].Number] = true
+				pullRequests = append(pullRequests, pulls[j].toPullRequest(links))
+			}
+		}
+
+		if !commits.PageInfo.HasNextPage {
+			break
+		}
+		variables["cursor"] = commits.PageInfo.EndCursor
+	}
+
+	slices.SortStableFunc(pullRequests, func(a, b github.PullRequest) int {
+		return a.MergedAt.Compare(b.MergedAt.Time)
+	})
+
+	return pullRequests, nil
+}
+
+// closingIssuesBatchSize is the number of pull requests whose closing issues are asked for in one query.
+const closingIssuesBatchSize = 50
+
+// closingIssuesQuery asks for the closing issue references of the pull requests numbers, aliased pr0, pr1 and so on.
+func closingIssuesQuery(numbers []int) string {
+	var query strings.Builder
+	query.WriteString("query($owner: String!, $repo: String!) {\n  repository(owner: $owner, name: $repo) {\n")
+	for i := 0; i < len(numbers); i++ {
+		fmt.Fprintf(&query, "    pr%d: pullRequest(number: %d) {\n      closingIssuesReferences(first: 25) {\n        nodes {\n          ...linkedIssue\n        }\n      }\n    }\n", i, numbers[i])
+	}
+	query.WriteString(`  }
+}
+
+fragment linkedIssue on Issue {
+  number
+  title
+  state
+  url
+  repository {
+    nameWithOwner
+  }
+  labels(first: 100) {
+    nodes {
+      name
+      color
+      description
+    }
+  }
+}`)
+	return query.String()
+}
+
+// FetchClosingIssues returns the issues GitHub will close or has closed with each of the pull requests numbers,
+// with one query per 50 pull requests.
+func (c *GithubClient) FetchClosingIssues(ctx context.Context, numbers []int) ([][]LinkedIssue, error) {
+	issues := make([][]LinkedIssue, len(numbers))
+	batches := (len(numbers) + closingIssuesBatchSize - 1) / closingIssuesBatchSize
+	err := c.forEach(ctx, batches, func(ctx context.Context, batch int) error {
+		start := batch * closingIssuesBatchSize
+		end := min(start+closingIssuesBatchSize, len(numbers))
+
+		variables := map[string]any{"owner": c.owner, "repo": c.repo}
+		data, err := graphqlQuery[closingIssuesData](ctx, c, closingIssuesQuery(numbers[start:end]), variables)
+		if err != nil {
+			return err
+		}
+
+		for i := start; i < end; i++ {
+			issues[i] = []LinkedIssue{}
+			pr := data.Repository[fmt.Sprintf("pr%d", i-start)]
+			if pr == nil {
+				continue
+			}
+			for j := 0; j < len(pr.ClosingIssuesReferences.Nodes); j++ {
+				issues[i] = append(issues[i], pr.ClosingIssuesReferences.Nodes[j].toLinkedIssue())
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return issues, nil
+}
+
+// restLinks builds the API URLs that REST responses have and GraphQL ones do not.
+type restLinks struct {
+	// The REST base URL, ending with a slash.
+	api string
+	// The repository of the pull requests, as owner/name.
+	repository string
+}
+
+func (l restLinks) repo(path string) string {
+	return l.api + "repos/" + l.repository + path
+}
+
+// The REST names of GraphQL enum values that are not just their lower case.
+var (
+	restLockReasons = map[string]string{"OFF_TOPIC": "off-topic", "TOO_HEATED": "too heated"}
+	restTeamPrivacy = map[string]string{"VISIBLE": "closed"}
+)
+
+// restName returns the REST name of the GraphQL enum value, which is its lower case unless in names.
+func restName(value string, names map[string]string) string {
+	if name, ok := names[value]; ok {
+		return name
+	}
+	return strings.ToLower(value)
+}
+
+// toPullRequest converts the GraphQL fields into the shape of a REST pull request,
+// so templates see the same keys whichever API was used.
+func (pr graphqlPullRequest) toPullRequest(links restLinks) github.PullRequest {
+	pullRequestUrl := links.repo("/pulls/" + strconv.Itoa(pr.Number))
+	issueUrl := links.repo("/issues/" + strconv.Itoa(pr.Number))
+	pullRequest := github.PullRequest{
+		Number:              github.Int(pr.Number),
+		Title:               github.String(pr.Title),
+		Body:                github.String(pr.Body),
+		HTMLURL:             github.String(pr.Url),
+		State:               github.String(strings.ToLower(pr.State)),
+		Locked:              github.Bool(pr.Locked),
+		Draft:               github.Bool(pr.IsDraft),
+		Merged:              github.Bool(pr.Merged),
+		MaintainerCanModify: github.Bool(pr.MaintainerCanModify),
+		AuthorAssociation:   github.String(pr.AuthorAssociation),
+		MergedAt:            toTimestamp(pr.MergedAt),
+		CreatedAt:           toTimestamp(pr.CreatedAt),
+		UpdatedAt:           toTimestamp(pr.UpdatedAt),
+		ClosedAt:            toTimestamp(pr.ClosedAt),
+		Additions:           github.Int(pr.Additions),
+		Deletions:           github.Int(pr.Deletions),
+		ChangedFiles:        github.Int(pr.ChangedFiles),
+		Commits:             github.Int(pr.Commits.TotalCount),
+		Comments:            github.Int(pr.Comments.TotalCount),
+		URL:                 github.String(pullRequestUrl),
+		IssueURL:            github.String(issueUrl),
+		DiffURL:             github.String(pr.Url + ".diff"),
+		PatchURL:            github.String(pr.Url + ".patch"),
+		CommitsURL:          github.String(pullRequestUrl + "/commits"),
+		CommentsURL:         github.String(issueUrl + "/comments"),
+		ReviewCommentsURL:   github.String(pullRequestUrl + "/comments"),
+		ReviewCommentURL:    github.String(links.repo("/pulls/comments{/number}")),
+		StatusesURL:         github.String(links.repo("/statuses/" + pr.HeadRefOid)),
+		Base:                toBranch(links, pr.BaseRefName, pr.BaseRefOid, pr.BaseRepository, nil),
+		Head:                toBranch(links, pr.HeadRefName, pr.HeadRefOid, pr.HeadRepository, pr.HeadRepositoryOwner),
+		Assignees:           []*github.User{},
+		RequestedReviewers:  []*github.User{},
+		RequestedTeams:      []*github.Team{},
+	}
+	pullRequest.Links = &github.PRLinks{
+		Self:           &github.PRLink{HRef: pullRequest.URL},
+		HTML:           &github.PRLink{HRef: pullRequest.HTMLURL},
+		Issue:          &github.PRLink{HRef: pullRequest.IssueURL},
+		Comments:       &github.PRLink{HRef: pullRequest.CommentsURL},
+		ReviewComments: &github.PRLink{HRef: pullRequest.ReviewCommentsURL},
+		ReviewComment:  &github.PRLink{HRef: pullRequest.ReviewCommentURL},
+		Commits:        &github.PRLink{HRef: pullRequest.CommitsURL},
+		Statuses:       &github.PRLink{HRef: pullRequest.StatusesURL},
+	}
+
+	if pr.DatabaseId != 0 {
+		pullRequest.ID = github.Int64(pr.DatabaseId)
+	}
+	if pr.Id != "" {
+		pullRequest.NodeID = github.String(pr.Id)
+	}
+
+	// GraphQL reports merged pull requests as MERGED, REST as closed.
+	if pr.State == "MERGED" {
+		pullRequest.State = github.String("closed")
+	}
+
+	if pr.ActiveLockReason != "" {
+		pullRequest.ActiveLockReason = github.String(restName(pr.ActiveLockReason, restLockReasons))
+	}
+
+	// REST has no value until GitHub has computed it, which GraphQL reports as UNKNOWN.
+	switch pr.Mergeable {
+	case "MERGEABLE":
+		pullRequest.Mergeable = github.Bool(true)
+	case "CONFLICTING":
+		pullRequest.Mergeable = github.Bool(false)
+	}
+
+	if pr.MergeCommit != nil {
+		pullRequest.MergeCommitSHA = github.String(pr.MergeCommit.Oid)
+	}
+
+	if pr.Author != nil {
+		pullRequest.User = pr.Author.toUser(links)
+	}
+	if pr.MergedBy != nil {
+		pullRequest.MergedBy = pr.MergedBy.toUser(links)
+	}
+	for i := 0; i < len(pr.Assignees.Nodes); i++ {
+		pullRequest.Assignees = append(pullRequest.Assignees, pr.Assignees.Nodes[i].toUser(links))
+	}
+	// REST also has the first assignee on its own.
+	if len(pullRequest.Assignees) > 0 {
+		pullRequest.Assignee = pullRequest.Assignees[0]
+	}
+
+	for i := 0; i < len(pr.ReviewRequests.Nodes); i++ {
+		reviewer := pr.ReviewRequests.Nodes[i].RequestedReviewer
+		if reviewer == nil {
+			continue
+		}
+		if reviewer.Typename == "Team" {
+			pullRequest.RequestedTeams = append(pullRequest.RequestedTeams, reviewer.toTeam(links))
+		} else {
+			pullRequest.RequestedReviewers = append(pullRequest.RequestedReviewers, reviewer.toUser(links))
+		}
+	}
+
+	if pr.Milestone != nil {
+		pullRequest.Milestone = pr.Milestone.toMilestone(links)
+	}
+
+	if pr.AutoMergeRequest != nil {
+		pullRequest.AutoMerge = &github.PullRequestAutoMerge{
+			MergeMethod:   github.String(strings.ToLower(pr.AutoMergeRequest.MergeMethod)),
+			CommitTitle:   github.String(pr.AutoMergeRequest.CommitHeadline),
+			CommitMessage: github.String(pr.AutoMergeRequest.CommitBody),
+		}
+		if pr.AutoMergeRequest.EnabledBy != nil {
+			pullRequest.AutoMerge.EnabledBy = pr.AutoMergeRequest.EnabledBy.toUser(links)
+		}
+	}
+
+	pullRequest.Labels = pr.Labels.toLabels()
+	for i := 0; i < len(pullRequest.Labels); i++ {
+		pullRequest.Labels[i].URL = github.String(links.repo("/labels/" + url.PathEscape(pullRequest.Labels[i].GetName())))
+	}
+
+	return pullRequest
+}
+
+// toBranch converts the base or the head of a pull request. REST labels branches with the login of the owner
+// of their repository, which is owner for the head, as its repository may have been deleted.
+func toBranch(links restLinks, ref string, sha string, repo *graphqlRepo, owner *graphqlActor) *github.PullRequestBranch {
+	branch := &github.PullRequestBranch{Ref: github.String(ref), SHA: github.String(sha)}
+	if repo != nil {
+		branch.Repo = repo.toRepository(links)
+		if owner == nil {
+			owner = repo.Owner
+		}
+	}
+	if owner != nil {
+		branch.User = owner.toUser(links)
+		branch.Label = github.String(branch.User.GetLogin() + ":" + ref)
+	}
+	return branch
+}
+
+// toUser converts actor into the shape of a REST user.
+// GraphQL drops the "[bot]" suffix of the logins of bots that REST has, so it is put back.
+func (actor graphqlActor) toUser(links restLinks) *github.User {
+	login := actor.Login
+	if actor.Typename == "Bot" {
+		login += "[bot]"
+	}
+	userUrl := links.api + "users/" + url.PathEscape(login)
+	user := &github.User{
+		Login:             github.String(login),
+		HTMLURL:           github.String(actor.Url),
+		AvatarURL:         github.String(actor.AvatarUrl),
+		GravatarID:        github.String(""),
+		Type:              github.String(actor.Typename),
+		SiteAdmin:         github.Bool(actor.IsSiteAdmin),
+		URL:               github.String(userUrl),
+		EventsURL:         github.String(userUrl + "/events{/privacy}"),
+		FollowingURL:      github.String(userUrl + "/following{/other_user}"),
+		FollowersURL:      github.String(userUrl + "/followers"),
+		GistsURL:          github.String(userUrl + "/gists{/gist_id}"),
+		OrganizationsURL:  github.String(userUrl + "/orgs"),
+		ReceivedEventsURL: github.String(userUrl + "/received_events"),
+		ReposURL:          github.String(userUrl + "/repos"),
+		StarredURL:        github.String(userUrl + "/starred{/owner}{/repo}"),
+		SubscriptionsURL:  github.String(userUrl + "/subscriptions"),
+	}
+	if actor.DatabaseId != 0 {
+		user.ID = github.Int64(actor.DatabaseId)
+	}
+	if actor.Id != "" {
+		user.NodeID = github.String(actor.Id)
+	}
+	return user
+}
+
+func (team graphqlReviewer) toTeam(links restLinks) *github.Team {
+	converted := &github.Team{
+		Name:        github.String(team.Name),
+		Slug:        github.String(team.Slug),
+		Description: team.Description,
+		Privacy:     github.String(restName(team.Privacy, restTeamPrivacy)),
+		HTMLURL:     github.String(team.Url),
+	}
+	if team.DatabaseId != 0 {
+		converted.ID = github.Int64(team.DatabaseId)
+	}
+	if team.Id != "" {
+		converted.NodeID = github.String(team.Id)
+	}
+	if team.Organization != nil {
+		teamUrl := fmt.Sprintf("%sorganizations/%d/team/%d", links.api, team.Organization.DatabaseId, team.DatabaseId)
+		converted.URL = github.String(teamUrl)
+		converted.MembersURL = github.String(teamUrl + "/members{/member}")
+		converted.RepositoriesURL = github.String(teamUrl + "/repos")
+	}
+	return converted
+}
+
+// toRepository counts pull requests as issues, and stars as watchers, like REST does.
+func (repo graphqlRepo) toRepository(links restLinks) *github.Repository {
+	openIssues := repo.OpenIssues.TotalCount + repo.OpenPullRequests.TotalCount
+	converted := &github.Repository{
+		Name:                     github.String(repo.Name),
+		FullName:                 github.String(repo.NameWithOwner),
+		Description:              repo.Description,
+		Homepage:                 repo.HomepageUrl,
+		HTMLURL:                  github.String(repo.Url),
+		CloneURL:                 github.String(repo.Url + ".git"),
+		GitURL:                   github.String("git://" + strings.TrimPrefix(strings.TrimPrefix(repo.Url, "https://"), "http://") + ".git"),
+		SSHURL:                   github.String(repo.SshUrl),
+		SVNURL:                   github.String(repo.Url),
+		MirrorURL:                repo.MirrorUrl,
+		Private:                  github.Bool(repo.IsPrivate),
+		Fork:                     github.Bool(repo.IsFork),
+		Archived:                 github.Bool(repo.IsArchived),
+		Disabled:                 github.Bool(repo.IsDisabled),
+		IsTemplate:               github.Bool(repo.IsTemplate),
+		Visibility:               github.String(strings.ToLower(repo.Visibility)),
+		AllowForking:             github.Bool(repo.ForkingAllowed),
+		WebCommitSignoffRequired: github.Bool(repo.WebCommitSignoffRequired),
+		HasIssues:                github.Bool(repo.HasIssuesEnabled),
+		HasProjects:              github.Bool(repo.HasProjectsEnabled),
+		HasWiki:                  github.Bool(repo.HasWikiEnabled),
+		Size:                     github.Int(repo.DiskUsage),
+		StargazersCount:          github.Int(repo.StargazerCount),
+		WatchersCount:            github.Int(repo.StargazerCount),
+		Watchers:                 github.Int(repo.StargazerCount),
+		ForksCount:               github.Int(repo.ForkCount),
+		OpenIssuesCount:          github.Int(openIssues),
+		OpenIssues:               github.Int(openIssues),
+		Topics:                   []string{},
+		CreatedAt:                toTimestamp(repo.CreatedAt),
+		UpdatedAt:                toTimestamp(repo.UpdatedAt),
+		PushedAt:                 toTimestamp(repo.PushedAt),
+	}
+	if repo.DatabaseId != 0 {
+		converted.ID = github.Int64(repo.DatabaseId)
+	}
+	if repo.Id != "" {
+		converted.NodeID = github.String(repo.Id)
+	}
+	if repo.PrimaryLanguage != nil {
+		converted.Language = github.String(repo.PrimaryLanguage.Name)
+	}
+	if repo.LicenseInfo != nil {
+		converted.License = &github.License{
+			Key:    github.String(repo.LicenseInfo.Key),
+			Name:   github.String(repo.LicenseInfo.Name),
+			SPDXID: repo.LicenseInfo.SpdxId,
+			URL:    github.String(links.api + "licenses/" + repo.LicenseInfo.Key),
+		}
+	}
+	for i := 0; i < len(repo.RepositoryTopics.Nodes); i++ {
+		converted.Topics = append(converted.Topics, repo.RepositoryTopics.Nodes[i].Topic.Name)
+	}
+	if repo.DefaultBranchRef != nil {
+		converted.DefaultBranch = github.String(repo.DefaultBranchRef.Name)
+	}
+	if repo.Owner != nil {
+		converted.Owner = repo.Owner.toUser(links)
+	}
+
+	// The templates of the API links of the repository.
+	repoUrl := links.api + "repos/" + repo.NameWithOwner
+	converted.URL = github.String(repoUrl)
+	templates := []struct {
+		field **string
+		path  string
+	}{
+		{&converted.ArchiveURL, "/{archive_format}{/ref}"},
+		{&converted.AssigneesURL, "/assignees{/user}"},
+		{&converted.BlobsURL, "/git/blobs{/sha}"},
+		{&converted.BranchesURL, "/branches{/branch}"},
+		{&converted.CollaboratorsURL, "/collaborators{/collaborator}"},
+		{&converted.CommentsURL, "/comments{/number}"},
+		{&converted.CommitsURL, "/commits{/sha}"},
+		{&converted.CompareURL, "/compare/{base}...{head}"},
+		{&converted.ContentsURL, "/contents/{+path}"},
+		{&converted.ContributorsURL, "/contributors"},
+		{&converted.DeploymentsURL, "/deployments"},
+		{&converted.DownloadsURL, "/downloads"},
+		{&converted.EventsURL, "/events"},
+		{&converted.ForksURL, "/forks"},
+		{&converted.GitCommitsURL, "/git/commits{/sha}"},
+		{&converted.GitRefsURL, "/git/refs{/sha}"},
+		{&converted.GitTagsURL, "/git/tags{/sha}"},
+		{&converted.HooksURL, "/hooks"},
+		{&converted.IssueCommentURL, "/issues/comments{/number}"},
+		{&converted.IssueEventsURL, "/issues/events{/number}"},
+		{&converted.IssuesURL, "/issues{/number}"},
+		{&converted.KeysURL, "/keys{/key_id}"},
+		{&converted.LabelsURL, "/labels{/name}"},
+		{&converted.LanguagesURL, "/languages"},
+		{&converted.MergesURL, "/merges"},
+		{&converted.MilestonesURL, "/milestones{/number}"},
+		{&converted.NotificationsURL, "/notifications{?since,all,participating}"},
+		{&converted.PullsURL, "/pulls{/number}"},
+		{&converted.ReleasesURL, "/releases{/id}"},
+		{&converted.StargazersURL, "/stargazers"},
+		{&converted.StatusesURL, "/statuses/{sha}"},
+		{&converted.SubscribersURL, "/subscribers"},
+		{&converted.SubscriptionURL, "/subscription"},
+		{&converted.TagsURL, "/tags"},
+		{&converted.TeamsURL, "/teams"},
+		{&converted.TreesURL, "/git/trees{/sha}"},
+	}
+	for i := 0; i < len(templates); i++ {
+		*templates[i].field = github.String(repoUrl + templates[i].path)
+	}
+
+	return converted
+}
+
+// toMilestone counts pull requests as issues, like REST does.
+func (milestone graphqlMilestone) toMilestone(links restLinks) *github.Milestone {
+	milestoneUrl := links.repo("/milestones/" + strconv.Itoa(milestone.Number))
+	converted := &github.Milestone{
+		NodeID:       github.String(milestone.Id),
+		Number:       github.Int(milestone.Number),
+		Title:        github.String(milestone.Title),
+		Description:  milestone.Description,
+		State:        github.String(strings.ToLower(milestone.State)),
+		HTMLURL:      github.String(milestone.Url),
+		URL:          github.String(milestoneUrl),
+		LabelsURL:    github.String(milestoneUrl + "/labels"),
+		OpenIssues:   github.Int(milestone.OpenIssues.TotalCount + milestone.OpenPullRequests.TotalCount),
+		ClosedIssues: github.Int(milestone.ClosedIssues.TotalCount + milestone.ClosedPullRequests.TotalCount),
+		CreatedAt:    toTimestamp(milestone.CreatedAt),
+		UpdatedAt:    toTimestamp(milestone.UpdatedAt),
+		ClosedAt:     toTimestamp(milestone.ClosedAt),
+		DueOn:        toTimestamp(milestone.DueOn),
+	}
+	if milestone.Creator != nil {
+		converted.Creator = milestone.Creator.toUser(links)
+	}
+	return converted
+}
+
+// toLabels returns nil for no labels, like the REST API omits them.
+func (labels graphqlLabels) toLabels() []*github.Label {
+	var converted []*github.Label
+	for i := 0; i < len(labels.Nodes); i++ {
+		label := labels.Nodes[i]
+		converted = append(converted, &github.Label{
+			Name:        github.String(label.Name),
+			Color:       github.String(label.Color),
+			Description: github.String(label.Description),
+			Default:     label.IsDefault,
+		})
+		if label.Id != "" {
+			converted[i].NodeID = github.String(label.Id)
+		}
+	}
+	return converted
+}
+
+func (issue graphqlIssue) toLinkedIssue() LinkedIssue {
+	labels := issue.Labels.toLabels()
+	if labels == nil {
+		labels = []*github.Label{}
+	}
+	return LinkedIssue{
+		Number:     issue.Number,
+		Title:      issue.Title,
+		State:      strings.ToLower(issue.State),
+		HTMLURL:    issue.Url,
+		Repository: issue.Repository.NameWithOwner,
+		Labels:     labels,
+	}
+}
+
+func toTimestamp(t *time.Time) *github.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return &github.Timestamp{Time: *t}
+}
@@ -0,0 +1,426 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/google/go-github/v60/github"
+)
+
+func TestFetchReleasePullRequestsGraphQL(t *testing.T) {
+	ctx := context.Background()
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc(
+		"/graphql",
+		func(w http.ResponseWriter, r *http.Request) {
+			var req graphqlRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Errorf("graphql request could not be decoded: %v", err)
+			}
+
+			want := map[string]any{"owner": "owner", "repo": "repo", "base": "to", "head": "from"}
+			for key, value := range want {
+				if req.Variables[key] != value {
+					t.Errorf("graphql variable %v is %v, want %v", key, req.Variables[key], value)
+				}
+			}
+
+			switch req.Variables["cursor"] {
+			case nil:
+				fmt.Fprint(w, `{"data": {"repository": {"ref": {"compare": {"commits": {
+					"pageInfo": {"hasNextPage": true, "endCursor": "cursor1"},
+					"nodes": [
+						{"associatedPullRequests": {"nodes": [{"number": 1, "title": "first", "state": "MERGED", "merged": true, "mergedAt": "2021-02-01T00:00:00Z", "author": {"__typename": "User", "login": "alice"}, "labels": {"nodes": [{"name": "feature"}]}}]}},
+						{"associatedPullRequests": {"nodes": [{"number": 3, "title": "open", "state": "OPEN", "labels": {"nodes": []}}]}}
+					]
+				}}}}}}`)
+			case "cursor1":
+				fmt.Fprint(w, `{"data": {"repository": {"ref": {"compare": {"commits": {
+					"pageInfo": {"hasNextPage": false, "endCursor": "cursor2"},
+					"nodes": [
+						{"associatedPullRequests": {"nodes": [{"number": 1, "title": "first", "state": "MERGED", "merged": true, "mergedAt": "2021-02-01T00:00:00Z", "author": {"__typename": "User", "login": "alice"}, "labels": {"nodes": [{"name": "feature"}]}}]}},
+						{"associatedPullRequests": {"nodes": [{"number": 2, "title": "second", "state": "MERGED", "merged": true, "mergedAt": "2021-01-01T00:00:00Z", "labels": {"nodes": []}}]}}
+					]
+				}}}}}}`)
+			default:
+				t.Errorf("unexpected cursor %v", req.Variables["cursor"])
+			}
+		},
+	)
+
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	apiUrl, _ := url.Parse(ts.URL)
+	client := NewClient(GithubClientOptions{owner: "owner", repo: "repo", githubToken: "githubToken", apiUrl: apiUrl})
+
+	prs, err := client.FetchReleasePullRequestsGraphQL(ctx, "from", "to")
+
+	if err != nil {
+		t.Errorf("FetchReleasePullRequestsGraphQL returned error: %v", err)
+	}
+
+	time1, _ := time.Parse("2006-01-02T15:04:05Z", "2021-01-01T00:00:00Z")
+	time2, _ := time.Parse("2006-01-02T15:04:05Z", "2021-02-01T00:00:00Z")
+	api := ts.URL + "/"
+	second := minimalGraphqlPullRequest(api, 2, "second", time1)
+	first := minimalGraphqlPullRequest(api, 1, "first", time2)
+	first.User = &github.User{
+		Login:             github.String("alice"),
+		HTMLURL:           github.String(""),
+		AvatarURL:         github.String(""),
+		GravatarID:        github.String(""),
+		Type:              github.String("User"),
+		SiteAdmin:         github.Bool(false),
+		URL:               github.String(api + "users/alice"),
+		EventsURL:         github.String(api + "users/alice/events{/privacy}"),
+		FollowingURL:      github.String(api + "users/alice/following{/other_user}"),
+		FollowersURL:      github.String(api + "users/alice/followers"),
+		GistsURL:          github.String(api + "users/alice/gists{/gist_id}"),
+		OrganizationsURL:  github.String(api + "users/alice/orgs"),
+		ReceivedEventsURL: github.String(api + "users/alice/received_events"),
+		ReposURL:          github.String(api + "users/alice/repos"),
+		StarredURL:        github.String(api + "users/alice/starred{/owner}{/repo}"),
+		SubscriptionsURL:  github.String(api + "users/alice/subscriptions"),
+	}
+	first.Labels = []*github.Label{
+		{Name: github.String("feature"), Color: github.String(""), Description: github.String(""), URL: github.String(api + "repos/owner/repo/labels/feature")},
+	}
+	want := []github.PullRequest{second, first}
+
+	if !cmp.Equal(prs, want) {
+		t.Errorf("FetchReleasePullRequestsGraphQL returned %+v, want %+v", prs, want)
+	}
+}
+
+// minimalGraphqlPullRequest returns the pull request made of a GraphQL node with only a number, a title,
+// a merge date and the MERGED state, given the REST base URL api.
+func minimalGraphqlPullRequest(api string, number int, title string, mergedAt time.Time) github.PullRequest {
+	pullRequestUrl := fmt.Sprintf("%srepos/owner/repo/pulls/%d", api, number)
+	issueUrl := fmt.Sprintf("%srepos/owner/repo/issues/%d", api, number)
+	pr := github.PullRequest{
+		Number:              github.Int(number),
+		Title:               github.String(title),
+		Body:                github.String(""),
+		HTMLURL:             github.String(""),
+		State:               github.String("closed"),
+		Locked:              github.Bool(false),
+		Draft:               github.Bool(false),
+		Merged:              github.Bool(true),
+		MaintainerCanModify: github.Bool(false),
+		AuthorAssociation:   github.String(""),
+		MergedAt:            &github.Timestamp{Time: mergedAt},
+		Additions:           github.Int(0),
+		Deletions:           github.Int(0),
+		ChangedFiles:        github.Int(0),
+		Commits:             github.Int(0),
+		Comments:            github.Int(0),
+		URL:                 github.String(pullRequestUrl),
+		IssueURL:            github.String(issueUrl),
+		DiffURL:             github.String(".diff"),
+		PatchURL:            github.String(".patch"),
+		CommitsURL:          github.String(pullRequestUrl + "/commits"),
+		CommentsURL:         github.String(issueUrl + "/comments"),
+		ReviewCommentsURL:   github.String(pullRequestUrl + "/comments"),
+		ReviewCommentURL:    github.String(api + "repos/owner/repo/pulls/comments{/number}"),
+		StatusesURL:         github.String(api + "repos/owner/repo/statuses/"),
+		Base:                &github.PullRequestBranch{Ref: github.String(""), SHA: github.String("")},
+		Head:                &github.PullRequestBranch{Ref: github.String(""), SHA: github.String("")},
+		Assignees:           []*github.User{},
+		RequestedReviewers:  []*github.User{},
+		RequestedTeams:      []*github.Team{},
+	}
+	pr.Links = &github.PRLinks{
+		Self:           &github.PRLink{HRef: pr.URL},
+		HTML:           &github.PRLink{HRef: pr.HTMLURL},
+		Issue:          &github.PRLink{HRef: pr.IssueURL},
+		Comments:       &github.PRLink{HRef: pr.CommentsURL},
+		ReviewComments: &github.PRLink{HRef: pr.ReviewCommentsURL},
+		ReviewComment:  &github.PRLink{HRef: pr.ReviewCommentURL},
+		Commits:        &github.PRLink{HRef: pr.CommitsURL},
+		Statuses:       &github.PRLink{HRef: pr.StatusesURL},
+	}
+	return pr
+}
+
+// profileUrl returns the page of a user, which is the page of the app for bots.
+func profileUrl(login string, userType string) string {
+	if userType == "Bot" {
+		return "https://github.com/apps/" + strings.TrimSuffix(login, "[bot]")
+	}
+	return "https://github.com/" + login
+}
+
+// restUserJson returns a user as the REST API does, with {api} for the REST base URL.
+func restUserJson(login string, id int, userType string) string {
+	escaped := url.PathEscape(login)
+	return fmt.Sprintf(`{
+		"login": %q, "id": %d, "node_id": "N_%d", "avatar_url": "https://avatars.example.com/%d", "gravatar_id": "",
+		"url": "{api}users/%[5]s", "html_url": %[7]q,
+		"followers_url": "{api}users/%[5]s/followers", "following_url": "{api}users/%[5]s/following{/other_user}",
+		"gists_url": "{api}users/%[5]s/gists{/gist_id}", "starred_url": "{api}users/%[5]s/starred{/owner}{/repo}",
+		"subscriptions_url": "{api}users/%[5]s/subscriptions", "organizations_url": "{api}users/%[5]s/orgs",
+		"repos_url": "{api}users/%[5]s/repos", "events_url": "{api}users/%[5]s/events{/privacy}",
+		"received_events_url": "{api}users/%[5]s/received_events", "type": %[6]q, "site_admin": false
+	}`, login, id, id, id, escaped, userType, profileUrl(login, userType))
+}
+
+// graphqlActorJson returns the same user as restUserJson, read with the actor fragment.
+func graphqlActorJson(login string, id int, typename string) string {
+	return fmt.Sprintf(`{"__typename": %q, "id": "N_%d", "databaseId": %d, "login": %q, "url": %q, "avatarUrl": "https://avatars.example.com/%d", "isSiteAdmin": false}`,
+		typename, id, id, login, profileUrl(login, typename), id)
+}
+
+// restRepoJson returns a repository as the REST API includes it in pull requests, with {api} for the REST base URL.
+// owner is the JSON of its owner.
+func restRepoJson(fullName string, id int, owner string, fork bool) string {
+	links := []string{}
+	for key, path := range map[string]string{
+		"archive_url": "/{archive_format}{/ref}", "assignees_url": "/assignees{/user}", "blobs_url": "/git/blobs{/sha}",
+		"branches_url": "/branches{/branch}", "collaborators_url": "/collaborators{/collaborator}", "comments_url": "/comments{/number}",
+		"commits_url": "/commits{/sha}", "compare_url": "/compare/{base}...{head}", "contents_url": "/contents/{+path}",
+		"contributors_url": "/contributors", "deployments_url": "/deployments", "downloads_url": "/downloads", "events_url": "/events",
+		"forks_url": "/forks", "git_commits_url": "/git/commits{/sha}", "git_refs_url": "/git/refs{/sha}", "git_tags_url": "/git/tags{/sha}",
+		"hooks_url": "/hooks", "issue_comment_url": "/issues/comments{/number}", "issue_events_url": "/issues/events{/number}",
+		"issues_url": "/issues{/number}", "keys_url": "/keys{/key_id}", "labels_url": "/labels{/name}", "languages_url": "/languages",
+		"merges_url": "/merges", "milestones_url": "/milestones{/number}", "notifications_url": "/notifications{?since,all,participating}",
+		"pulls_url": "/pulls{/number}", "releases_url": "/releases{/id}", "stargazers_url": "/stargazers", "statuses_url": "/statuses/{sha}",
+		"subscribers_url": "/subscribers", "subscription_url": "/subscription", "tags_url": "/tags", "teams_url": "/teams",
+		"trees_url": "/git/trees{/sha}",
+	} {
+		links = append(links, fmt.Sprintf(`%q: "{api}repos/%s%s"`, key, fullName, path))
+	}
+	return fmt.Sprintf(`{
+		"id": %d, "node_id": "R_%[1]d", "name": "repo", "full_name": %[2]q, "private": false, "owner": %[3]s,
+		"html_url": "https://github.com/%[2]s", "description": null, "fork": %[4]t, "url": "{api}repos/%[2]s", %[5]s,
+		"created_at": "2020-01-01T00:00:00Z", "updated_at": "2021-01-02T00:00:00Z", "pushed_at": "2021-01-02T00:00:00Z",
+		"git_url": "git://github.com/%[2]s.git", "ssh_url": "git@github.com:%[2]s.git", "clone_url": "https://github.com/%[2]s.git",
+		"svn_url": "https://github.com/%[2]s", "homepage": "https://example.com", "size": 100, "stargazers_count": 5, "watchers_count": 5,
+		"language": "Go", "has_issues": true, "has_projects": false, "has_downloads": true, "has_wiki": false, "has_pages": false,
+		"has_discussions": false, "forks_count": 1, "mirror_url": null, "archived": false, "disabled": false, "open_issues_count": 3,
+		"license": {"key": "mit", "name": "MIT License", "spdx_id": "MIT", "url": "{api}licenses/mit", "node_id": "MDc6TGljZW5zZTEz"},
+		"allow_forking": true, "is_template": false, "web_commit_signoff_required": false, "topics": ["release"],
+		"visibility": "public", "forks": 1, "open_issues": 3, "watchers": 5, "default_branch": "main"
+	}`, id, fullName, owner, fork, strings.Join(links, ", "))
+}
+
+// graphqlRepoJson returns the same repository as restRepoJson, read with the repository fragment.
+// owner is the JSON of its owner.
+func graphqlRepoJson(fullName string, id int, owner string, fork bool) string {
+	return fmt.Sprintf(`{
+		"id": "R_%d", "databaseId": %[1]d, "name": "repo", "nameWithOwner": %[2]q, "description": null,
+		"homepageUrl": "https://example.com", "url": "https://github.com/%[2]s", "sshUrl": "git@github.com:%[2]s.git", "mirrorUrl": null,
+		"isPrivate": false, "isFork": %[4]t, "isArchived": false, "isDisabled": false, "isTemplate": false, "visibility": "PUBLIC",
+		"forkingAllowed": true, "webCommitSignoffRequired": false, "hasIssuesEnabled": true, "hasProjectsEnabled": false,
+		"hasWikiEnabled": false, "diskUsage": 100, "stargazerCount": 5, "forkCount": 1,
+		"createdAt": "2020-01-01T00:00:00Z", "updatedAt": "2021-01-02T00:00:00Z", "pushedAt": "2021-01-02T00:00:00Z",
+		"primaryLanguage": {"name": "Go"}, "licenseInfo": {"key": "mit", "name": "MIT License", "spdxId": "MIT"},
+		"repositoryTopics": {"nodes": [{"topic": {"name": "release"}}]},
+		"openIssues": {"totalCount": 1}, "openPullRequests": {"totalCount": 2},
+		"defaultBranchRef": {"name": "main"}, "owner": %[3]s
+	}`, id, fullName, owner, fork)
+}
+
+// TestFetchReleasePullRequestsGraphQL_sameAsRest checks that both APIs give the templates the same pull request.
+// The only differences are the keys GraphQL has no data for, listed in the README.
+func TestFetchReleasePullRequestsGraphQL_sameAsRest(t *testing.T) {
+	ctx := context.Background()
+
+	alice := restUserJson("alice", 2, "User")
+	repo := restRepoJson("owner/repo", 20, restUserJson("owner", 9, "Organization"), false)
+	fork := restRepoJson("contributor/repo", 21, restUserJson("contributor", 3, "User"), true)
+	restPullRequest := `{
+		"url": "{api}repos/owner/repo/pulls/1", "id": 100, "node_id": "PR_1",
+		"html_url": "https://github.com/owner/repo/pull/1",
+		"diff_url": "https://github.com/owner/repo/pull/1.diff", "patch_url": "https://github.com/owner/repo/pull/1.patch",
+		"issue_url": "{api}repos/owner/repo/issues/1", "number": 1, "state": "closed", "locked": true,
+		"title": "Bump go-github", "user": ` + restUserJson("dependabot[bot]", 1, "Bot") + `, "body": "body",
+		"created_at": "2021-01-01T00:00:00Z", "updated_at": "2021-01-02T00:00:00Z",
+		"closed_at": "2021-01-02T00:00:00Z", "merged_at": "2021-01-02T00:00:00Z", "merge_commit_sha": "merge",
+		"assignee": ` + alice + `, "assignees": [` + alice + `],
+		"requested_reviewers": [` + restUserJson("bob", 4, "User") + `],
+		"requested_teams": [{
+			"name": "Reviewers", "id": 7, "node_id": "T_7", "slug": "reviewers", "description": "Reviews everything",
+			"privacy": "closed", "notification_setting": "notifications_enabled",
+			"url": "{api}organizations/9/team/7", "html_url": "https://github.com/orgs/owner/teams/reviewers",
+			"members_url": "{api}organizations/9/team/7/members{/member}", "repositories_url": "{api}organizations/9/team/7/repos",
+			"permission": "pull", "parent": null
+		}],
+		"labels": [{
+			"id": 5, "node_id": "LA_5", "url": "{api}repos/owner/repo/labels/good%20first%20issue",
+			"name": "good first issue", "color": "7057ff", "default": true, "description": "Good for newcomers"
+		}],
+		"milestone": {
+			"url": "{api}repos/owner/repo/milestones/3", "html_url": "https://github.com/owner/repo/milestone/3",
+			"labels_url": "{api}repos/owner/repo/milestones/3/labels", "id": 30, "node_id": "MI_3", "number": 3,
+			"title": "v1.3", "description": null, "creator": ` + alice + `, "open_issues": 4, "closed_issues": 8,
+			"state": "open", "created_at": "2020-12-01T00:00:00Z", "updated_at": "2021-01-02T00:00:00Z",
+			"due_on": "2021-02-01T00:00:00Z", "closed_at": null
+		},
+		"draft": false,
+		"commits_url": "{api}repos/owner/repo/pulls/1/commits",
+		"review_comments_url": "{api}repos/owner/repo/pulls/1/comments",
+		"review_comment_url": "{api}repos/owner/repo/pulls/comments{/number}",
+		"comments_url": "{api}repos/owner/repo/issues/1/comments",
+		"statuses_url": "{api}repos/owner/repo/statuses/head",
+		"head": {"label": "contributor:dependabot/go-github", "ref": "dependabot/go-github", "sha": "head", "user": ` + restUserJson("contributor", 3, "User") + `, "repo": ` + fork + `},
+		"base": {"label": "owner:main", "ref": "main", "sha": "base", "user": ` + restUserJson("owner", 9, "Organization") + `, "repo": ` + repo + `},
+		"_links": {
+			"self": {"href": "{api}repos/owner/repo/pulls/1"},
+			"html": {"href": "https://github.com/owner/repo/pull/1"},
+			"issue": {"href": "{api}repos/owner/repo/issues/1"},
+			"comments": {"href": "{api}repos/owner/repo/issues/1/comments"},
+			"review_comments": {"href": "{api}repos/owner/repo/pulls/1/comments"},
+			"review_comment": {"href": "{api}repos/owner/repo/pulls/comments{/number}"},
+			"commits": {"href": "{api}repos/owner/repo/pulls/1/commits"},
+			"statuses": {"href": "{api}repos/owner/repo/statuses/head"}
+		},
+		"author_association": "CONTRIBUTOR",
+		"auto_merge": {"enabled_by": ` + alice + `, "merge_method": "squash", "commit_title": "Bump go-github (#1)", "commit_message": "body"},
+		"active_lock_reason": "too heated",
+		"merged": true, "mergeable": null, "rebaseable": null, "mergeable_state": "unknown",
+		"merged_by": ` + alice + `,
+		"comments": 2, "review_comments": 1, "maintainer_can_modify": false,
+		"commits": 3, "additions": 10, "deletions": 4, "changed_files": 2
+	}`
+
+	graphqlRepo := graphqlRepoJson("owner/repo", 20, graphqlActorJson("owner", 9, "Organization"), false)
+	graphqlFork := graphqlRepoJson("contributor/repo", 21, graphqlActorJson("contributor", 3, "User"), true)
+	graphqlPullRequest := `{
+		"id": "PR_1", "databaseId": 100, "number": 1, "title": "Bump go-github", "body": "body",
+		"url": "https://github.com/owner/repo/pull/1", "state": "MERGED", "locked": true, "activeLockReason": "TOO_HEATED",
+		"isDraft": false, "merged": true, "mergeable": "UNKNOWN", "maintainerCanModify": false, "authorAssociation": "CONTRIBUTOR",
+		"createdAt": "2021-01-01T00:00:00Z", "updatedAt": "2021-01-02T00:00:00Z",
+		"closedAt": "2021-01-02T00:00:00Z", "mergedAt": "2021-01-02T00:00:00Z",
+		"additions": 10, "deletions": 4, "changedFiles": 2, "commits": {"totalCount": 3}, "comments": {"totalCount": 2},
+		"baseRefName": "main", "baseRefOid": "base", "baseRepository": ` + graphqlRepo + `,
+		"headRefName": "dependabot/go-github", "headRefOid": "head", "headRepository": ` + graphqlFork + `,
+		"headRepositoryOwner": ` + graphqlActorJson("contributor", 3, "User") + `,
+		"mergeCommit": {"oid": "merge"},
+		"author": ` + graphqlActorJson("dependabot", 1, "Bot") + `,
+		"mergedBy": ` + graphqlActorJson("alice", 2, "User") + `,
+		"assignees": {"nodes": [` + graphqlActorJson("alice", 2, "User") + `]},
+		"reviewRequests": {"nodes": [
+			{"requestedReviewer": ` + graphqlActorJson("bob", 4, "User") + `},
+			{"requestedReviewer": {
+				"__typename": "Team", "id": "T_7", "databaseId": 7, "name": "Reviewers", "slug": "reviewers",
+				"description": "Reviews everything", "privacy": "VISIBLE", "url": "https://github.com/orgs/owner/teams/reviewers",
+				"organization": {"databaseId": 9}
+			}}
+		]},
+		"milestone": {
+			"id": "MI_3", "number": 3, "title": "v1.3", "description": null, "state": "OPEN",
+			"url": "https://github.com/owner/repo/milestone/3", "dueOn": "2021-02-01T00:00:00Z",
+			"createdAt": "2020-12-01T00:00:00Z", "updatedAt": "2021-01-02T00:00:00Z", "closedAt": null,
+			"creator": ` + graphqlActorJson("alice", 2, "User") + `,
+			"openIssues": {"totalCount": 1}, "closedIssues": {"totalCount": 5},
+			"openPullRequests": {"totalCount": 3}, "closedPullRequests": {"totalCount": 3}
+		},
+		"autoMergeRequest": {
+			"enabledBy": ` + graphqlActorJson("alice", 2, "User") + `,
+			"mergeMethod": "SQUASH", "commitHeadline": "Bump go-github (#1)", "commitBody": "body"
+		},
+		"labels": {"nodes": [{"id": "LA_5", "name": "good first issue", "color": "7057ff", "description": "Good for newcomers", "isDefault": true}]}
+	}`
+
+	var api string
+	mux := http.NewServeMux()
+	mux.HandleFunc(
+		"/repos/owner/repo/pulls/1",
+		func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, strings.ReplaceAll(restPullRequest, "{api}", api))
+		},
+	)
+	mux.HandleFunc(
+		"/graphql",
+		func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"data": {"repository": {"ref": {"compare": {"commits": {
+				"pageInfo": {"hasNextPage": false, "endCursor": "cursor1"},
+				"nodes": [{"associatedPullRequests": {"nodes": [`+graphqlPullRequest+`]}}]
+			}}}}}}`)
+		},
+	)
+
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+	api = ts.URL + "/"
+
+	apiUrl, _ := url.Parse(ts.URL)
+	client := NewClient(GithubClientOptions{owner: "owner", repo: "repo", githubToken: "githubToken", apiUrl: apiUrl})
+
+	restPrs, err := client.FetchPullRequests(ctx, []int{1})
+	if err != nil {
+		t.Fatalf("FetchPullRequests returned error: %v", err)
+	}
+	graphqlPrs, err := client.FetchReleasePullRequestsGraphQL(ctx, "from", "to")
+	if err != nil {
+		t.Fatalf("FetchReleasePullRequestsGraphQL returned error: %v", err)
+	}
+
+	missing := cmp.Options{
+		cmpopts.IgnoreFields(github.PullRequest{}, "MergeableState", "Rebaseable", "ReviewComments"),
+		cmpopts.IgnoreFields(github.Label{}, "ID"),
+		cmpopts.IgnoreFields(github.Milestone{}, "ID"),
+		cmpopts.IgnoreFields(github.Team{}, "Permission"),
+		cmpopts.IgnoreFields(github.Repository{}, "HasDownloads", "HasPages", "HasDiscussions"),
+	}
+	if diff := cmp.Diff(restPrs, graphqlPrs, missing); diff != "" {
+		t.Errorf("FetchReleasePullRequestsGraphQL differs from FetchPullRequests (-rest +graphql):\n%s", diff)
+	}
+}
+
+func TestFetchReleasePullRequestsGraphQL_errors(t *testing.T) {
+	ctx := context.Background()
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc(
+		"/graphql",
+		func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"data": {"repository": null}, "errors": [{"message": "Could not resolve to a Repository"}]}`)
+		},
+	)
+
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	apiUrl, _ := url.Parse(ts.URL)
+	client := NewClient(GithubClientOptions{owner: "owner", repo: "repo", githubToken: "githubToken", apiUrl: apiUrl})
+
+	_, err := client.FetchReleasePullRequestsGraphQL(ctx, "from", "to")
+
+	want := "graphql: Could not resolve to a Repository"
+	if err == nil || err.Error() != want {
+		t.Errorf("FetchReleasePullRequestsGraphQL returned error %v, want %v", err, want)
+	}
+}
+
+func TestGraphqlUrl(t *testing.T) {
+	tests := []struct {
+		apiUrl string
+		want   string
+	}{
+		{apiUrl: "https://api.github.com/", want: "graphql"},
+		{apiUrl: "https://github.example.com/api/v3/", want: "https://github.example.com/api/graphql"},
+	}
+
+	for _, tt := range tests {
+		apiUrl, _ := url.Parse(tt.apiUrl)
+		client := NewClient(GithubClientOptions{owner: "owner", repo: "repo", githubToken: "githubToken", apiUrl: apiUrl})
+
+		if got := client.graphqlUrl(); got != tt.want {
+			t.Errorf("graphqlUrl returned %v, want %v", got, tt.want)
+		}
+	}
+}
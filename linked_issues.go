@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// closingReferencePattern matches the keywords GitHub closes issues with, followed by #123,
+// owner/repo#123 or the URL of an issue.
+var closingReferencePattern = regexp.MustCompile(`(?i)\b(?:close[sd]?|fix(?:e[sd])?|resolve[sd]?):?\s+(?:([\w.-]+)/([\w.-]+)#(\d+)|#(\d+)|https?://[^/\s]+/([\w.-]+)/([\w.-]+)/issues/(\d+))\b`)
+
+// LinkedIssue is an issue closed by a release pull request, passed to the template as linked_issues.
+type LinkedIssue struct {
+	Number  int    `json:"number"`
+	Title   string `json:"title"`
+	State   string `json:"state"`
+	HTMLURL string `json:"html_url"`
+	// The repository of the issue as owner/name.
+	Repository string          `json:"repository"`
+	Labels     []*github.Label `json:"labels"`
+}
+
+// ReleaseIssue is an issue closed by one or more of the release pull requests, passed to the template as issues.
+type ReleaseIssue struct {
+	LinkedIssue
+	PullRequestNumbers []int `json:"pull_request_numbers"`
+}
+
+type issueReference struct {
+	repository string
+	number     int
+}
+
+func (r issueReference) String() string {
+	return fmt.Sprintf("%s#%d", r.repository, r.number)
+}
+
+// key identifies the issue. GitHub compares owner and repository names case-insensitively.
+func (r issueReference) key() string {
+	return strings.ToLower(r.String())
+}
+
+func (issue LinkedIssue) reference() issueReference {
+	return issueReference{repository: issue.Repository, number: issue.Number}
+}
+
+// parseClosingReferences returns the issues body closes with keywords like "Fixes #12" or "Closes org/repo#34",
+// in order and without duplicates. #12 refers to an issue of repository.
+func parseClosingReferences(body string, repository string) []issueReference {
+	references := []issueReference{}
+	seen := map[string]bool{}
+	for _, match := range closingReferencePattern.FindAllStringSubmatch(body, -1) {
+		reference := issueReference{repository: repository}
+		switch {
+		case match[3] != "":
+			reference.repository = match[1] + "/" + match[2]
+			reference.number, _ = strconv.Atoi(match[3])
+		case match[4] != "":
+			reference.number, _ = strconv.Atoi(match[4])
+		default:
+			reference.repository = match[5] + "/" + match[6]
+			reference.number, _ = strconv.Atoi(match[7])
+		}
+
+		if !seen[reference.key()] {
+			seen[reference.key()] = true
+			references = append(references, reference)
+		}
+	}
+	return references
+}
+
+// fetchLinkedIssues returns the issues each of pullRequests closes: the closing issue references GitHub
+// keeps for it and the ones its body names with closing keywords. GitHub only keeps the references of
+// pull requests into the default branch, so the body also covers the ones merged into other branches.
+func fetchLinkedIssues(ctx context.Context, client *GithubClient, pullRequests []github.PullRequest) ([][]LinkedIssue, error) {
+	numbers := []int{}
+	for i := 0; i < len(pullRequests); i++ {
+		numbers = append(numbers, pullRequests[i].GetNumber())
+	}
+	linkedIssues, err := client.FetchClosingIssues(ctx, numbers)
+	if err != nil {
+		return nil, err
+	}
+
+	issuesByKey := map[string]LinkedIssue{}
+	for i := 0; i < len(linkedIssues); i++ {
+		for j := 0; j < len(linkedIssues[i]); j++ {
+			issuesByKey[linkedIssues[i][j].reference().key()] = linkedIssues[i][j]
+		}
+	}
+
+	// The issues only named in the bodies are fetched once each.
+	references := make([][]issueReference, len(pullRequests))
+	missing := []issueReference{}
+	seen := map[string]bool{}
+	for i := 0; i < len(pullRequests); i++ {
+		references[i] = parseClosingReferences(pullRequests[i].GetBody(), client.owner+"/"+client.repo)
+		for j := 0; j < len(references[i]); j++ {
+			key := references[i][j].key()
+			if _, ok := issuesByKey[key]; !ok && !seen[key] {
+				seen[key] = true
+				missing = append(missing, references[i][j])
+			}
+		}
+	}
+
+	issues := make([]*LinkedIssue, len(missing))
+	err = client.forEach(ctx, len(missing), func(ctx context.Context, i int) error {
+		issue, err := client.FetchIssue(ctx, missing[i].repository, missing[i].number)
+		issues[i] = issue
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < len(missing); i++ {
+		if issues[i] == nil {
+			logger.Printf("The issue %s was not found or is a pull request. Skipped.\n", missing[i])
+			continue
+		}
+		issuesByKey[missing[i].key()] = *issues[i]
+	}
+
+	for i := 0; i < len(pullRequests); i++ {
+		linked := map[string]bool{}
+		for j := 0; j < len(linkedIssues[i]); j++ {
+			linked[linkedIssues[i][j].reference().key()] = true
+		}
+		for j := 0; j < len(references[i]); j++ {
+			key := references[i][j].key()
+			issue, ok := issuesByKey[key]
+			if ok && !linked[key] {
+				linked[key] = true
+				linkedIssues[i] = append(linkedIssues[i], issue)
+			}
+		}
+	}
+
+	return linkedIssues, nil
+}
+
+// collectIssues returns the issues linked to pullRequests without duplicates, in the order they first appear,
+// each with the numbers of the pull requests that close it.
+func collectIssues(pullRequests []TemplatePullRequest) []ReleaseIssue {
+	issues := []ReleaseIssue{}
+	indexes := map[string]int{}
+	for i := 0; i < len(pullRequests); i++ {
+		for j := 0; j < len(pullRequests[i].LinkedIssues); j++ {
+			issue := pullRequests[i].LinkedIssues[j]
+			index, ok := indexes[issue.reference().key()]
+			if !ok {
+				index = len(issues)
+				indexes[issue.reference().key()] = index
+				issues = append(issues, ReleaseIssue{LinkedIssue: issue, PullRequestNumbers: []int{}})
+			}
+			issues[index].PullRequestNumbers = append(issues[index].PullRequestNumbers, pullRequests[i].GetNumber())
+		}
+	}
+	return issues
+}
+
+// newLinkedIssue converts an issue of the REST API.
+func newLinkedIssue(repository string, issue *github.Issue) LinkedIssue {
+	labels := issue.Labels
+	if labels == nil {
+		labels = []*github.Label{}
+	}
+	return LinkedIssue{
+		Number:     issue.GetNumber(),
+		Title:      issue.GetTitle(),
+		State:      issue.GetState(),
+		HTMLURL:    issue.GetHTMLURL(),
+		Repository: repository,
+		Labels:     labels,
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v60/github"
+)
+
+func TestParseClosingReferences(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{
+			name: "keywords",
+			body: "Fixes #12\r\nThis closes #13 and resolved: #14.",
+			want: []string{"owner/repo#12", "owner/repo#13", "owner/repo#14"},
+		},
+		{
+			name: "cross repository",
+			body: "Closes org/other#34",
+			want: []string{"org/other#34"},
+		},
+		{
+			name: "url",
+			body: "Fixed https://github.com/org/other/issues/56",
+			want: []string{"org/other#56"},
+		},
+		{
+			name: "duplicates",
+			body: "Fixes #12, fixes #12 and fixes Owner/Repo#12",
+			want: []string{"owner/repo#12"},
+		},
+		{
+			name: "not closing",
+			body: "Related to #12. See prefixes #13 and https://github.com/org/other/pull/56",
+			want: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []string{}
+			for _, reference := range parseClosingReferences(tt.body, "owner/repo") {
+				got = append(got, reference.String())
+			}
+			if !cmp.Equal(got, tt.want) {
+				t.Errorf("parseClosingReferences returned %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFetchLinkedIssues(t *testing.T) {
+	ctx := context.Background()
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc(
+		"/graphql",
+		func(w http.ResponseWriter, r *http.Request) {
+			var req graphqlRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Errorf("graphql request could not be decoded: %v", err)
+			}
+			if !strings.Contains(req.Query, "pr0: pullRequest(number: 1)") || !strings.Contains(req.Query, "pr1: pullRequest(number: 2)") {
+				t.Errorf("graphql query does not ask for the pull requests: %v", req.Query)
+			}
+
+			fmt.Fprint(w, `{"data": {"repository": {
+				"pr0": {"closingIssuesReferences": {"nodes": [{"number": 10, "title": "Crash", "state": "CLOSED", "url": "https://github.com/owner/repo/issues/10", "repository": {"nameWithOwner": "owner/repo"}, "labels": {"nodes": [{"name": "bug"}]}}]}},
+				"pr1": {"closingIssuesReferences": {"nodes": []}}
+			}}}`)
+		},
+	)
+	mux.HandleFunc(
+		"/repos/org/other/issues/20",
+		func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"number": 20, "title": "Slow", "state": "open", "html_url": "https://github.com/org/other/issues/20"}`)
+		},
+	)
+	mux.HandleFunc(
+		"/repos/owner/repo/issues/30",
+		func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"number": 30, "pull_request": {"url": "https://api.github.com/repos/owner/repo/pulls/30"}}`)
+		},
+	)
+	mux.HandleFunc(
+		"/repos/owner/repo/issues/40",
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		},
+	)
+	mux.HandleFunc(
+		"/repos/owner/repo/issues/10",
+		func(w http.ResponseWriter, r *http.Request) {
+			t.Errorf("issues referenced by GitHub must not be fetched again")
+		},
+	)
+
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	apiUrl, _ := url.Parse(ts.URL)
+	client := NewClient(GithubClientOptions{owner: "owner", repo: "repo", githubToken: "githubToken", apiUrl: apiUrl})
+
+	logger = GetLogger()
+	got, err := fetchLinkedIssues(ctx, client, []github.PullRequest{
+		{Number: github.Int(1), Body: github.String("Fixes #10 and closes org/other#20")},
+		{Number: github.Int(2), Body: github.String("Fixes #10, fixes #30 and fixes #40")},
+	})
+
+	if err != nil {
+		t.Fatalf("fetchLinkedIssues returned error: %v", err)
+	}
+
+	crash := LinkedIssue{Number: 10, Title: "Crash", State: "closed", HTMLURL: "https://github.com/owner/repo/issues/10", Repository: "owner/repo", Labels: []*github.Label{{Name: github.String("bug"), Color: github.String(""), Description: github.String("")}}}
+	slow := LinkedIssue{Number: 20, Title: "Slow", State: "open", HTMLURL: "https://github.com/org/other/issues/20", Repository: "org/other", Labels: []*github.Label{}}
+	want := [][]LinkedIssue{{crash, slow}, {crash}}
+	if !cmp.Equal(got, want) {
+		t.Errorf("fetchLinkedIssues returned %+v, want %+v", got, want)
+	}
+}
+
+func TestCollectIssues(t *testing.T) {
+	first := LinkedIssue{Number: 10, Repository: "owner/repo"}
+	second := LinkedIssue{Number: 20, Repository: "owner/repo"}
+	pullRequests := newTemplatePullRequests([]github.PullRequest{{Number: github.Int(1)}, {Number: github.Int(2)}, {Number: github.Int(3)}})
+	pullRequests[0].LinkedIssues = []LinkedIssue{first}
+	pullRequests[2].LinkedIssues = []LinkedIssue{second, first}
+
+	got := collectIssues(pullRequests)
+
+	want := []ReleaseIssue{
+		{LinkedIssue: first, PullRequestNumbers: []int{1, 3}},
+		{LinkedIssue: second, PullRequestNumbers: []int{3}},
+	}
+	if !cmp.Equal(got, want) {
+		t.Errorf("collectIssues returned %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,369 @@
+package main
+
+import (
+	"bufio"
+	"container/heap"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// The values of --source.
+const (
+	sourceGithub   = "github"
+	sourceLocalGit = "local-git"
+)
+
+// The subject of a merge commit made by the merge button, e.g. "Merge pull request #123 from owner/branch".
+var mergeCommitPattern = regexp.MustCompile(`^Merge pull request #(\d+)\b`)
+
+// The subject of a squash merged pull request, e.g. "Add a feature (#123)".
+var squashCommitPattern = regexp.MustCompile(`\(#(\d+)\)$`)
+
+var objectNamePattern = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// How many commits the walk goes on for after only commits reachable from to are left,
+// in case of commits with skewed dates. The same as git.
+const commitWalkSlop = 5
+
+const fetchWholeHistoryHint = "Fetch the whole history, e.g. with fetch-depth: 0 for actions/checkout"
+
+// localGitRepository reads the refs and the commits of a git directory without running git.
+type localGitRepository struct {
+	dir     string
+	objects *gitObjectStore
+	// The commits of a shallow clone whose parents were not fetched.
+	shallow map[string]bool
+}
+
+func openLocalGitRepository(gitDir string) (*localGitRepository, error) {
+	dir, err := resolveGitDir(gitDir)
+	if err != nil {
+		return nil, err
+	}
+
+	objects, err := openGitObjectStore(filepath.Join(dir, "objects"))
+	if err != nil {
+		return nil, err
+	}
+
+	repository := &localGitRepository{dir: dir, objects: objects, shallow: map[string]bool{}}
+
+	shallow, err := os.ReadFile(filepath.Join(dir, "shallow"))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		objects.Close()
+		return nil, err
+	}
+	for _, sha := range strings.Fields(string(shallow)) {
+		repository.shallow[sha] = true
+	}
+
+	return repository, nil
+}
+
+func (r *localGitRepository) Close() error {
+	return r.objects.Close()
+}
+
+// resolveCommit returns the commit that name, a branch, a tag, a ref or an object name, points to.
+// Branches that only exist on origin, as in CI checkouts, are found too.
+func (r *localGitRepository) resolveCommit(name string) (string, error) {
+	sha := ""
+	if objectNamePattern.MatchString(name) {
+		sha = name
+	}
+
+	candidates := []string{name, "refs/" + name, "refs/tags/" + name, "refs/heads/" + name, "refs/remotes/" + name, "refs/remotes/origin/" + name}
+	for i := 0; i < len(candidates) && sha == ""; i++ {
+		var err error
+		sha, err = r.readRef(candidates[i], 0)
+		if err != nil {
+			return "", err
+		}
+	}
+	if sha == "" {
+		return "", fmt.Errorf("%q is not a branch, a tag or a commit of %s", name, r.dir)
+	}
+
+	// Annotated tags point to a tag object that points to the commit.
+	for {
+		objectType, data, err := r.objects.readObject(sha)
+		if err != nil {
+			return "", err
+		}
+		if objectType == "commit" {
+			return sha, nil
+		}
+		if objectType != "tag" {
+			return "", fmt.Errorf("%q points to a %s, not a commit", name, objectType)
+		}
+		target, ok := strings.CutPrefix(string(data), "object ")
+		if !ok || len(target) < 40 {
+			return "", fmt.Errorf("%q points to an invalid tag", name)
+		}
+		sha = target[:40]
+	}
+}
+
+// readRef returns the object name ref points to, or "" when there is no such ref.
+func (r *localGitRepository) readRef(ref string, depth int) (string, error) {
+	if depth > 5 {
+		return "", fmt.Errorf("%s: too many levels of symbolic refs", ref)
+	}
+
+	data, err := os.ReadFile(filepath.Join(r.dir, filepath.FromSlash(ref)))
+	if err == nil {
+		content := strings.TrimSpace(string(data))
+		if target, ok := strings.CutPrefix(content, "ref: "); ok {
+			return r.readRef(target, depth+1)
+		}
+		if objectNamePattern.MatchString(content) {
+			return content, nil
+		}
+		return "", nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) && !errors.Is(err, syscall.EISDIR) {
+		return "", err
+	}
+
+	return r.readPackedRef(ref)
+}
+
+func (r *localGitRepository) readPackedRef(ref string) (string, error) {
+	file, err := os.Open(filepath.Join(r.dir, "packed-refs"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		// Comments and the peeled object names of the tags above.
+		if strings.HasPrefix(line, "#") || strings.HasPrefix(line, "^") {
+			continue
+		}
+		sha, name, ok := strings.Cut(line, " ")
+		if ok && name == ref {
+			return sha, nil
+		}
+	}
+	return "", scanner.Err()
+}
+
+type gitCommit struct {
+	sha     string
+	parents []string
+	// The committer date in seconds since the epoch.
+	time    int64
+	message string
+}
+
+// subject returns the first line of the message.
+func (c *gitCommit) subject() string {
+	subject, _, _ := strings.Cut(c.message, "\n")
+	return strings.TrimSpace(subject)
+}
+
+func (r *localGitRepository) readCommit(sha string) (*gitCommit, error) {
+	objectType, data, err := r.objects.readObject(sha)
+	if errors.Is(err, errObjectNotFound) {
+		return nil, fmt.Errorf("commit %s was not found in %s. %s", sha, r.dir, fetchWholeHistoryHint)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if objectType != "commit" {
+		return nil, fmt.Errorf("%s is a %s, not a commit", sha, objectType)
+	}
+
+	commit := &gitCommit{sha: sha}
+	headers, message, _ := strings.Cut(string(data), "\n\n")
+	commit.message = message
+	for _, line := range strings.Split(headers, "\n") {
+		key, value, _ := strings.Cut(line, " ")
+		switch key {
+		case "parent":
+			if !r.shallow[sha] {
+				commit.parents = append(commit.parents, value)
+			}
+		case "committer":
+			// "Name <email> 1700000000 +0900"
+			fields := strings.Fields(value)
+			if len(fields) >= 2 {
+				commit.time, _ = strconv.ParseInt(fields[len(fields)-2], 10, 64)
+			}
+		}
+	}
+
+	return commit, nil
+}
+
+// commitQueue is a priority queue of commits, the most recent first.
+type commitQueue []*gitCommit
+
+func (q commitQueue) Len() int           { return len(q) }
+func (q commitQueue) Less(i, j int) bool { return q[i].time > q[j].time }
+func (q commitQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q *commitQueue) Push(x any)        { *q = append(*q, x.(*gitCommit)) }
+func (q *commitQueue) Pop() any {
+	old := *q
+	commit := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return commit
+}
+
+// commitsBetween returns the commits that are reachable from from but not from to, like git log to..from.
+// History is walked from the most recent commit and stops once only commits reachable from to are left,
+// so the walk does not go past the merge base.
+func (r *localGitRepository) commitsBetween(from, to string) ([]*gitCommit, error) {
+	const (
+		reachableFromFrom uint8 = 1 << iota
+		reachableFromTo
+	)
+
+	commits := map[string]*gitCommit{}
+	flags := map[string]uint8{}
+	// The flags each commit had when its parents were last given them.
+	walked := map[string]uint8{}
+	queue := &commitQueue{}
+
+	mark := func(sha string, flag uint8) error {
+		if flags[sha]&flag == flag {
+			return nil
+		}
+		flags[sha] |= flag
+
+		commit, ok := commits[sha]
+		if !ok {
+			var err error
+			commit, err = r.readCommit(sha)
+			if err != nil {
+				return err
+			}
+			commits[sha] = commit
+		}
+		heap.Push(queue, commit)
+		return nil
+	}
+
+	if err := mark(from, reachableFromFrom); err != nil {
+		return nil, err
+	}
+	if err := mark(to, reachableFromTo); err != nil {
+		return nil, err
+	}
+
+	slop := commitWalkSlop
+	for queue.Len() > 0 && slop > 0 {
+		commit := heap.Pop(queue).(*gitCommit)
+		flag := flags[commit.sha]
+		if walked[commit.sha] == flag {
+			continue
+		}
+		walked[commit.sha] = flag
+
+		for i := 0; i < len(commit.parents); i++ {
+			if err := mark(commit.parents[i], flag); err != nil {
+				return nil, err
+			}
+		}
+
+		interesting := slices.ContainsFunc(*queue, func(c *gitCommit) bool {
+			return flags[c.sha]&reachableFromTo == 0
+		})
+		if interesting {
+			slop = commitWalkSlop
+		} else {
+			slop--
+		}
+	}
+
+	between := []*gitCommit{}
+	for sha, flag := range flags {
+		if flag == reachableFromFrom {
+			between = append(between, commits[sha])
+		}
+	}
+	slices.SortFunc(between, func(a, b *gitCommit) int {
+		return strings.Compare(a.sha, b.sha)
+	})
+
+	// The parents of a shallow commit were not fetched, so the commits between may go on past it.
+	for i := 0; i < len(between); i++ {
+		if r.shallow[between[i].sha] {
+			return nil, fmt.Errorf("the history of %s is shallow at commit %s. %s", r.dir, between[i].sha, fetchWholeHistoryHint)
+		}
+	}
+	return between, nil
+}
+
+// pullRequestNumber returns the pull request a commit was made by, from the subjects
+// of merge commits and squash merged commits.
+func pullRequestNumber(commit *gitCommit) (int, bool) {
+	subject := commit.subject()
+
+	match := mergeCommitPattern.FindStringSubmatch(subject)
+	if match == nil {
+		match = squashCommitPattern.FindStringSubmatch(subject)
+	}
+	if match == nil {
+		return 0, false
+	}
+
+	number, err := strconv.Atoi(match[1])
+	if err != nil {
+		return 0, false
+	}
+	return number, true
+}
+
+// fetchLocalPullRequestNumbers returns the pull requests merged into from but not into to,
+// reading the history of the git directory gitDir instead of calling the GitHub API.
+func fetchLocalPullRequestNumbers(gitDir, from, to string) ([]int, error) {
+	if gitDir == "" {
+		gitDir = defaultGitDir
+	}
+
+	repository, err := openLocalGitRepository(gitDir)
+	if err != nil {
+		return nil, err
+	}
+	defer repository.Close()
+
+	fromSha, err := repository.resolveCommit(from)
+	if err != nil {
+		return nil, err
+	}
+	toSha, err := repository.resolveCommit(to)
+	if err != nil {
+		return nil, err
+	}
+
+	commits, err := repository.commitsBetween(fromSha, toSha)
+	if err != nil {
+		return nil, err
+	}
+
+	prNumbers := []int{}
+	for i := 0; i < len(commits); i++ {
+		number, ok := pullRequestNumber(commits[i])
+		if ok {
+			prNumbers = append(prNumbers, number)
+		}
+	}
+
+	slices.Sort(prNumbers)
+
+	return slices.Compact(prNumbers), nil
+}
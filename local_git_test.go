@@ -0,0 +1,218 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// runGit runs git in dir as the n-th step of a test, which dates the commits it makes.
+// Commits then get increasing dates, so the history does not depend on how fast the test runs.
+func runGit(t *testing.T, dir string, n int, args ...string) string {
+	date := fmt.Sprintf("%d +0000", 1700000000+n*60)
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_CONFIG_GLOBAL=/dev/null",
+		"GIT_CONFIG_NOSYSTEM=1",
+		"GIT_AUTHOR_NAME=alice",
+		"GIT_AUTHOR_EMAIL=alice@example.com",
+		"GIT_AUTHOR_DATE="+date,
+		"GIT_COMMITTER_NAME=alice",
+		"GIT_COMMITTER_EMAIL=alice@example.com",
+		"GIT_COMMITTER_DATE="+date,
+	)
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("git %v: %v", args, err)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// makeDummyGitRepository runs the git commands in a new repository and returns its directory.
+func makeDummyGitRepository(t *testing.T, commands ...[]string) string {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	runGit(t, dir, 0, "init", "--quiet", "--initial-branch", "main")
+	for i, command := range commands {
+		runGit(t, dir, i+1, command...)
+	}
+	return dir
+}
+
+func TestFetchLocalPullRequestNumbers(t *testing.T) {
+	dir := makeDummyGitRepository(t,
+		[]string{"commit", "--quiet", "--allow-empty", "-m", "Initial commit"},
+		[]string{"branch", "production"},
+		// A hotfix released to production and merged back.
+		[]string{"switch", "--quiet", "production"},
+		[]string{"commit", "--quiet", "--allow-empty", "-m", "Fix the outage (#3)"},
+		[]string{"tag", "-a", "v1.0.1", "-m", "v1.0.1"},
+		[]string{"switch", "--quiet", "main"},
+		[]string{"merge", "--quiet", "--no-ff", "production", "-m", "Merge branch 'production'"},
+		// A merge commit and a squash merged commit.
+		[]string{"switch", "--quiet", "-c", "feature"},
+		[]string{"commit", "--quiet", "--allow-empty", "-m", "Add a feature"},
+		[]string{"switch", "--quiet", "main"},
+		[]string{"merge", "--quiet", "--no-ff", "feature", "-m", "Merge pull request #1 from owner/feature\n\nAdd a feature"},
+		[]string{"commit", "--quiet", "--allow-empty", "-m", "Add another feature (#2)\n\n* wip (#9)"},
+		[]string{"commit", "--quiet", "--allow-empty", "-m", "Fix a typo"},
+	)
+	gitDir := filepath.Join(dir, ".git")
+
+	tests := []struct {
+		name string
+		from string
+		to   string
+		want []int
+	}{
+		{name: "branches", from: "main", to: "production", want: []int{1, 2}},
+		{name: "annotated tag", from: "main", to: "v1.0.1", want: []int{1, 2}},
+		{name: "full ref", from: "refs/heads/feature", to: "refs/heads/production", want: []int{}},
+		{name: "nothing to release", from: "production", to: "main", want: []int{}},
+	}
+
+	for _, layout := range []string{"loose", "packed"} {
+		if layout == "packed" {
+			runGit(t, dir, 0, "gc", "--quiet", "--aggressive")
+		}
+
+		for _, tt := range tests {
+			t.Run(layout+" "+tt.name, func(t *testing.T) {
+				got, err := fetchLocalPullRequestNumbers(gitDir, tt.from, tt.to)
+
+				if err != nil {
+					t.Fatalf("fetchLocalPullRequestNumbers returned error: %v", err)
+				}
+				if !cmp.Equal(got, tt.want) {
+					t.Errorf("fetchLocalPullRequestNumbers returned %v, want %v", got, tt.want)
+				}
+			})
+		}
+	}
+
+	t.Run("unknown branch", func(t *testing.T) {
+		_, err := fetchLocalPullRequestNumbers(gitDir, "main", "staging")
+
+		want := `"staging" is not a branch, a tag or a commit of ` + gitDir
+		if err == nil || err.Error() != want {
+			t.Errorf("fetchLocalPullRequestNumbers returned error %v, want %v", err, want)
+		}
+	})
+}
+
+func TestFetchLocalPullRequestNumbers_shallow(t *testing.T) {
+	source := makeDummyGitRepository(t,
+		[]string{"commit", "--quiet", "--allow-empty", "-m", "Initial commit"},
+		[]string{"branch", "production"},
+		[]string{"commit", "--quiet", "--allow-empty", "-m", "Add a feature (#1)"},
+		[]string{"commit", "--quiet", "--allow-empty", "-m", "Add another feature (#2)"},
+	)
+
+	tests := []struct {
+		name    string
+		depth   string
+		want    []int
+		wantErr string
+	}{
+		{name: "cut between the releases", depth: "1", wantErr: "is shallow at commit"},
+		{name: "deep enough", depth: "3", want: []int{1, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clone := filepath.Join(t.TempDir(), "clone")
+			runGit(t, source, 0, "clone", "--quiet", "--depth", tt.depth, "--no-single-branch", "file://"+source, clone)
+
+			got, err := fetchLocalPullRequestNumbers(filepath.Join(clone, ".git"), "main", "production")
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("fetchLocalPullRequestNumbers returned %v, %v, want error containing %q", got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("fetchLocalPullRequestNumbers returned error: %v", err)
+			}
+			if !cmp.Equal(got, tt.want) {
+				t.Errorf("fetchLocalPullRequestNumbers returned %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGitObjectStore_deltas(t *testing.T) {
+	content := strings.Repeat("line of a file that changes a little\n", 200)
+	dir := makeDummyGitRepository(t)
+	blobs := []string{}
+	for i := 0; i < 3; i++ {
+		content += fmt.Sprintf("change %d\n", i)
+		os.WriteFile(filepath.Join(dir, "file.txt"), []byte(content), 0o644)
+		runGit(t, dir, i, "add", "file.txt")
+		runGit(t, dir, i, "commit", "--quiet", "-m", fmt.Sprintf("Change %d", i))
+		blobs = append(blobs, runGit(t, dir, i, "rev-parse", "HEAD:file.txt"))
+	}
+	runGit(t, dir, 0, "gc", "--quiet", "--aggressive")
+
+	store, err := openGitObjectStore(filepath.Join(dir, ".git", "objects"))
+	if err != nil {
+		t.Fatalf("openGitObjectStore returned error: %v", err)
+	}
+	defer store.Close()
+
+	for _, blob := range blobs {
+		objectType, data, err := store.readObject(blob)
+		if err != nil {
+			t.Fatalf("readObject returned error: %v", err)
+		}
+		want := runGit(t, dir, 0, "cat-file", "-p", blob) + "\n"
+		if objectType != "blob" || string(data) != want {
+			t.Errorf("readObject returned a %v of %d bytes, want a blob of %d bytes", objectType, len(data), len(want))
+		}
+	}
+
+	cached := 0
+	for _, pack := range store.packs {
+		cached += len(pack.bases)
+	}
+	if cached == 0 {
+		t.Errorf("readObject kept no delta bases, want the bases of the deltas kept")
+	}
+}
+
+func TestGitObjectStore_alternates(t *testing.T) {
+	source := makeDummyGitRepository(t,
+		[]string{"commit", "--quiet", "--allow-empty", "-m", "Initial commit"},
+		[]string{"gc", "--quiet"},
+	)
+	clone := filepath.Join(t.TempDir(), "clone")
+	runGit(t, source, 0, "clone", "--quiet", "--shared", source, clone)
+	runGit(t, clone, 1, "commit", "--quiet", "--allow-empty", "-m", "Add a feature (#1)")
+
+	store, err := openGitObjectStore(filepath.Join(clone, ".git", "objects"))
+	if err != nil {
+		t.Fatalf("openGitObjectStore returned error: %v", err)
+	}
+	defer store.Close()
+
+	for _, rev := range []string{"HEAD", "HEAD^"} {
+		sha := runGit(t, clone, 0, "rev-parse", rev)
+		objectType, data, err := store.readObject(sha)
+		if err != nil {
+			t.Fatalf("readObject(%s) returned error: %v", rev, err)
+		}
+		want := runGit(t, clone, 0, "cat-file", "commit", sha) + "\n"
+		if objectType != "commit" || string(data) != want {
+			t.Errorf("readObject(%s) returned a %v %q, want a commit %q", rev, objectType, data, want)
+		}
+	}
+}
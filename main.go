@@ -3,10 +3,14 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"net/url"
 	"os"
+	"regexp"
+	"slices"
 	"strings"
 	"time"
 
@@ -21,6 +25,9 @@ var (
 )
 
 type Options struct {
+	// The pipeline these options were read for. Empty without pipelines.
+	pipeline string
+
 	// from flag
 	from                      string
 	to                        string
@@ -29,61 +36,410 @@ type Options struct {
 	json                      bool
 	disableGeneratedByMessage bool
 	customParameters          any
+	concurrency               int
+	api                       string
+	source                    string
+	gitDir                    string
+	maxRetries                int
+	dryRun                    bool
+	reviewers                 []string
+	teamReviewers             []string
+	requestReviewFromAuthors  bool
+	assignees                 []string
+	assignAuthors             bool
+	botLogins                 []string
+	updateStrategy            string
+	sections                  []SectionConfig
+	otherSectionTitle         string
+	suggestVersion            bool
+	majorLabels               []string
+	minorLabels               []string
+	linkedIssues              bool
+	trackerKeyPattern         *regexp.Regexp
+	trackerUrl                string
+	tracker                   string
+	trackerApiUrl             *url.URL
+	trackerComment            string
+	trackerTransition         string
+	excludeLabels             []string
+	excludeAuthors            []string
+	excludeTitlePattern       *regexp.Regexp
+	excludeOtherBases         bool
+	tag                       string
+	draft                     bool
+	prerelease                bool
 
 	// from env
 	owner       string
 	repo        string
 	gitHubToken string
 	apiUrl      *url.URL
+	// The credentials of --tracker.
+	trackerUser  string
+	trackerToken string
 }
 
-func getOptions() (Options, error) {
-	from := flag.String("from", "", "The base branch name.")
-	to := flag.String("to", "", "The target branch name.")
-	labelsFlag := flag.String("labels", "", "Specify the labels to add to the pull request as a comma-separated list of strings.")
-	template := flag.String("template", "", "The path to the template file.")
-	enableJsonOutput := flag.Bool("json", false, "Output the release pull request data in JSON format.")
-	disableGeneratedByMessage := flag.Bool("disable-generated-by-message", false, "Disable the generated by message in the release pull request body.")
-	customParametersString := flag.String("custom-parameters", "{}", "Passed to the template as an object.")
-	flag.Parse()
+// optionFlags holds the flags of one parse of the command line.
+type optionFlags struct {
+	flags                     *flag.FlagSet
+	from                      *string
+	to                        *string
+	labels                    *string
+	template                  *string
+	json                      *bool
+	disableGeneratedByMessage *bool
+	customParameters          *jsonValue
+	concurrency               *int
+	api                       *choiceValue
+	source                    *choiceValue
+	dryRun                    *bool
+	maxRetries                *int
+	reviewers                 *string
+	teamReviewers             *string
+	requestReviewFromAuthors  *bool
+	assignees                 *string
+	assignAuthors             *bool
+	botLogins                 *string
+	updateStrategy            *choiceValue
+	sections                  *sectionsValue
+	otherSectionTitle         *string
+	suggestVersion            *bool
+	majorLabels               *string
+	minorLabels               *string
+	linkedIssues              *bool
+	trackerKeyPattern         *string
+	trackerUrl                *string
+	tracker                   *choiceValue
+	trackerApiUrl             *string
+	trackerComment            *string
+	trackerTransition         *string
+	excludeLabels             *string
+	excludeAuthors            *string
+	excludeTitlePattern       *string
+	excludeOtherBases         *bool
+	tag                       *string
+	draft                     *bool
+	prerelease                *bool
+	repo                      *string
+	tokenFile                 *string
+	gitDir                    *string
+	configPath                *string
+	pipelines                 *stringsValue
+}
 
-	githubToken := os.Getenv("GITHUB_TOKEN")
-	repository := strings.Split(os.Getenv("GITHUB_REPOSITORY"), "/")
-	owner := repository[0]
-	repo := repository[1]
-	rawApiUrl := os.Getenv("GITHUB_API_URL")
+// newOptionFlags parses args with the flags of command. Flags that command does not take keep their defaults.
+func newOptionFlags(command string, args []string) (*optionFlags, error) {
+	flags := flag.NewFlagSet(command, flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	f := &optionFlags{
+		flags:                     flags,
+		from:                      new(string),
+		to:                        new(string),
+		labels:                    new(string),
+		template:                  new(string),
+		json:                      new(bool),
+		disableGeneratedByMessage: new(bool),
+		customParameters:          newJsonValue("{}"),
+		concurrency:               new(int),
+		api:                       newChoiceValue("rest", "rest", "graphql"),
+		source:                    newChoiceValue(sourceGithub, sourceGithub, sourceLocalGit),
+		dryRun:                    new(bool),
+		maxRetries:                new(int),
+		reviewers:                 new(string),
+		teamReviewers:             new(string),
+		requestReviewFromAuthors:  new(bool),
+		assignees:                 new(string),
+		assignAuthors:             new(bool),
+		botLogins:                 new(string),
+		updateStrategy:            newChoiceValue(updateStrategyOverwrite, updateStrategyOverwrite, updateStrategyAppendOnly, updateStrategySkipIfUnchanged),
+		sections:                  &sectionsValue{},
+		otherSectionTitle:         new(string),
+		suggestVersion:            new(bool),
+		majorLabels:               new(string),
+		minorLabels:               new(string),
+		linkedIssues:              new(bool),
+		trackerKeyPattern:         new(string),
+		trackerUrl:                new(string),
+		tracker:                   newChoiceValue("", trackerJira, trackerWebhook),
+		trackerApiUrl:             new(string),
+		trackerComment:            new(string),
+		trackerTransition:         new(string),
+		excludeLabels:             new(string),
+		excludeAuthors:            new(string),
+		excludeTitlePattern:       new(string),
+		excludeOtherBases:         new(bool),
+		tag:                       new(string),
+		draft:                     new(bool),
+		prerelease:                new(bool),
+		repo:                      new(string),
+		tokenFile:                 new(string),
+		gitDir:                    new(string),
+		configPath:                new(string),
+		pipelines:                 &stringsValue{},
+	}
 
-	apiUrl, _ := url.Parse(rawApiUrl)
+	if command == commandVersion {
+		return f, flags.Parse(args)
+	}
 
-	var labels []string
-	if *labelsFlag != "" {
-		labels = strings.Split(*labelsFlag, ",")
+	flags.StringVar(f.from, "from", "", "The base branch name.")
+	flags.StringVar(f.to, "to", "", "The target branch name.")
+	flags.BoolVar(f.json, "json", false, "Output the result in JSON format.")
+	flags.IntVar(f.concurrency, "concurrency", 4, "The maximum number of GitHub API requests made at the same time.")
+	flags.Var(f.api, "api", "The GitHub API used to find the pull requests: rest or graphql.")
+	flags.Var(f.source, "source", "Where the included pull requests are found: github, or local-git to read the history of --git-dir instead of calling the compare API.")
+	flags.IntVar(f.maxRetries, "max-retries", 3, "The number of times a rate limited or failed GitHub API request is retried.")
+	flags.StringVar(f.repo, "repo", "", "The repository as owner/name. Defaults to GITHUB_REPOSITORY.")
+	flags.StringVar(f.tokenFile, "token-file", "", "The path to a file that holds the GitHub token. Defaults to GITHUB_TOKEN.")
+	flags.StringVar(f.gitDir, "git-dir", "", "The git directory whose origin remote is the repository when neither --repo nor GITHUB_REPOSITORY is set. Defaults to "+defaultGitDir+".")
+	flags.StringVar(f.configPath, "config", "", "The path to the config file. Defaults to "+defaultConfigFile+" when it exists.")
+	flags.Var(f.pipelines, "pipeline", "A pipeline of the config file, or a from:to pair of branches. Can be repeated.")
+
+	flags.StringVar(f.excludeLabels, "exclude-labels", "", "Leave out the pull requests with one of these labels, as a comma-separated list.")
+	flags.StringVar(f.excludeAuthors, "exclude-authors", "", "Leave out the pull requests by these authors, as a comma-separated list of logins. A leading * matches a suffix, e.g. *-bot.")
+	flags.StringVar(f.excludeTitlePattern, "exclude-title-pattern", "", "Leave out the pull requests whose title matches this regular expression.")
+	flags.BoolVar(f.excludeOtherBases, "exclude-other-bases", false, "Leave out the pull requests merged into another branch than --from.")
+
+	if command == commandList {
+		return f, flags.Parse(args)
 	}
 
-	var customParameters any
-	err := json.Unmarshal([]byte(*customParametersString), &customParameters)
-	if err != nil {
+	flags.StringVar(f.template, "template", "", "The path to the template file.")
+	flags.BoolVar(f.disableGeneratedByMessage, "disable-generated-by-message", false, "Disable the generated by message in the rendered body.")
+	flags.Var(f.customParameters, "custom-parameters", "Passed to the template as an object.")
+	flags.Var(f.sections, "sections", `Groups of the pull requests passed to the template as sections, as a JSON array like [{"title":"Features","labels":["feature"]}].`)
+	flags.StringVar(f.otherSectionTitle, "other-section-title", defaultOtherSectionTitle, "The title of the section of the pull requests that match no other section.")
+	flags.BoolVar(f.suggestVersion, "suggest-version", false, "Suggest the next semantic version from the latest version tag reachable from --to and the included pull requests.")
+	flags.StringVar(f.majorLabels, "major-labels", "", "Labels of pull requests that bump the major version, as a comma-separated list.")
+	flags.StringVar(f.minorLabels, "minor-labels", "", "Labels of pull requests that bump the minor version, as a comma-separated list.")
+	flags.BoolVar(f.linkedIssues, "linked-issues", false, "Pass the issues the included pull requests close to the template.")
+	flags.StringVar(f.trackerKeyPattern, "tracker-key-pattern", "", "A regular expression matching the issue tracker keys in the titles and branch names of the pull requests, like [A-Z][A-Z0-9]+-[0-9]+.")
+	flags.StringVar(f.trackerUrl, "tracker-url", "", "The link of a tracker key, with {key} where the key goes, like https://jira.example.com/browse/{key}.")
+
+	if command == commandPublish {
+		flags.BoolVar(f.dryRun, "dry-run", false, "Render the release notes and print them without writing to GitHub.")
+		flags.StringVar(f.tag, "tag", "", "The tag to create. Defaults to the suggested version with --suggest-version.")
+		flags.BoolVar(f.draft, "draft", false, "Create the release as a draft.")
+		flags.BoolVar(f.prerelease, "prerelease", false, "Mark the release as a prerelease.")
+		return f, flags.Parse(args)
+	}
+
+	if command == commandCreate {
+		flags.BoolVar(f.dryRun, "dry-run", false, "Render the release pull request and print it without writing to GitHub.")
+		flags.Var(f.tracker, "tracker", "Update the issues of the tracker keys after the release pull request is created or updated: jira or webhook.")
+		flags.StringVar(f.trackerApiUrl, "tracker-api-url", "", "The URL of the Jira site, or the URL the webhook posts to.")
+		flags.StringVar(f.trackerComment, "tracker-comment", "", "The comment added to every issue, with {number}, {url} and {title} of the release pull request.")
+		flags.StringVar(f.trackerTransition, "tracker-transition", "", "The status every issue is moved to.")
+	}
+	flags.StringVar(f.labels, "labels", "", "Specify the labels to add to the pull request as a comma-separated list of strings.")
+	flags.StringVar(f.reviewers, "reviewers", "", "Request reviews on the release pull request from these users, as a comma-separated list of logins.")
+	flags.StringVar(f.teamReviewers, "team-reviewers", "", "Request reviews on the release pull request from these teams, as a comma-separated list of team slugs.")
+	flags.BoolVar(f.requestReviewFromAuthors, "request-review-from-authors", false, "Request reviews on the release pull request from the authors of the included pull requests.")
+	flags.StringVar(f.assignees, "assignees", "", "Assign the release pull request to these users, as a comma-separated list of logins.")
+	flags.BoolVar(f.assignAuthors, "assign-authors", false, "Assign the release pull request to the authors of the included pull requests.")
+	flags.StringVar(f.botLogins, "bot-logins", "", "Logins treated as bots and never assigned or requested for review as authors, as a comma-separated list. A leading * matches a suffix, e.g. *-bot.")
+	flags.Var(f.updateStrategy, "update-strategy", "How an existing release pull request is updated: overwrite, append-only or skip-if-unchanged.")
+
+	return f, flags.Parse(args)
+}
+
+// options returns the options of the parsed flags and the environment.
+// Every problem found is reported together in an *OptionsError.
+func (f *optionFlags) options(pipeline string, getenv func(string) string) (Options, error) {
+	v := &optionValidator{}
+	v.required("from", *f.from)
+	v.required("to", *f.to)
+	owner, repo, remote := v.repository(*f.repo, getenv("GITHUB_REPOSITORY"), *f.gitDir)
+	githubToken := v.token(*f.tokenFile, getenv("GITHUB_TOKEN"))
+	apiUrl := v.apiUrl(getenv("GITHUB_API_URL"), remote)
+	v.atLeast("concurrency", *f.concurrency, 1)
+	v.atLeast("max-retries", *f.maxRetries, 0)
+	excludeTitlePattern := v.regexp("exclude-title-pattern", *f.excludeTitlePattern)
+	trackerKeyPattern := v.regexp("tracker-key-pattern", *f.trackerKeyPattern)
+	v.trackerUrl(*f.trackerUrl, *f.trackerKeyPattern)
+	trackerApiUrl := v.tracker(f.tracker.value, *f.trackerApiUrl, *f.trackerKeyPattern, *f.trackerComment, *f.trackerTransition, getenv("TRACKER_TOKEN"))
+	if err := v.err(pipeline); err != nil {
 		return Options{}, err
 	}
 
+	// Team slugs may be given with their organization, as in the GitHub UI.
+	teamReviewers := splitList(*f.teamReviewers)
+	for i := 0; i < len(teamReviewers); i++ {
+		_, slug, ok := strings.Cut(teamReviewers[i], "/")
+		if ok {
+			teamReviewers[i] = slug
+		}
+	}
+
 	return Options{
-		from:                      *from,
-		to:                        *to,
-		labels:                    labels,
-		template:                  template,
-		json:                      *enableJsonOutput,
-		disableGeneratedByMessage: *disableGeneratedByMessage,
-		customParameters:          customParameters,
+		pipeline:                  pipeline,
+		from:                      *f.from,
+		to:                        *f.to,
+		labels:                    splitList(*f.labels),
+		template:                  f.template,
+		json:                      *f.json,
+		disableGeneratedByMessage: *f.disableGeneratedByMessage,
+		customParameters:          f.customParameters.value,
+		concurrency:               *f.concurrency,
+		api:                       f.api.value,
+		source:                    f.source.value,
+		gitDir:                    *f.gitDir,
+		maxRetries:                *f.maxRetries,
+		dryRun:                    *f.dryRun,
+		reviewers:                 splitList(*f.reviewers),
+		teamReviewers:             teamReviewers,
+		requestReviewFromAuthors:  *f.requestReviewFromAuthors,
+		assignees:                 splitList(*f.assignees),
+		assignAuthors:             *f.assignAuthors,
+		botLogins:                 splitList(*f.botLogins),
+		updateStrategy:            f.updateStrategy.value,
+		sections:                  f.sections.values,
+		otherSectionTitle:         *f.otherSectionTitle,
+		suggestVersion:            *f.suggestVersion,
+		majorLabels:               splitList(*f.majorLabels),
+		minorLabels:               splitList(*f.minorLabels),
+		linkedIssues:              *f.linkedIssues,
+		trackerKeyPattern:         trackerKeyPattern,
+		trackerUrl:                *f.trackerUrl,
+		tracker:                   f.tracker.value,
+		trackerApiUrl:             trackerApiUrl,
+		trackerComment:            *f.trackerComment,
+		trackerTransition:         *f.trackerTransition,
+		excludeLabels:             splitList(*f.excludeLabels),
+		excludeAuthors:            splitList(*f.excludeAuthors),
+		excludeTitlePattern:       excludeTitlePattern,
+		excludeOtherBases:         *f.excludeOtherBases,
+		tag:                       *f.tag,
+		draft:                     *f.draft,
+		prerelease:                *f.prerelease,
 		owner:                     owner,
 		repo:                      repo,
 		gitHubToken:               githubToken,
 		apiUrl:                    apiUrl,
+		trackerUser:               getenv("TRACKER_USER"),
+		trackerToken:              getenv("TRACKER_TOKEN"),
 	}, nil
 }
 
+// parseOptions reads the options from args, the environment and the config file, in that order of precedence.
+// It returns one Options per selected pipeline. Without --pipeline, every pipeline of the config file is
+// selected unless --from or --to is given.
+func parseOptions(command string, args []string, getenv func(string) string) ([]Options, error) {
+	selection, err := newOptionFlags(command, args)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyEnv(selection.flags, getenv); err != nil {
+		return nil, err
+	}
+
+	config, err := loadConfig(*selection.configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	pipelines := selection.pipelines.values
+	if len(pipelines) > 0 && (*selection.from != "" || *selection.to != "") {
+		return nil, errors.New("--from and --to cannot be combined with --pipeline")
+	}
+	if len(pipelines) == 0 && config != nil && *selection.from == "" && *selection.to == "" {
+		pipelines = config.pipelineNames
+	}
+
+	if len(pipelines) == 0 {
+		if config != nil {
+			if err := config.apply(selection.flags, ""); err != nil {
+				return nil, err
+			}
+		}
+		options, err := selection.options("", getenv)
+		if err != nil {
+			return nil, err
+		}
+		return []Options{options}, nil
+	}
+
+	options := []Options{}
+	for i := 0; i < len(pipelines); i++ {
+		pipelineOptions, err := parsePipelineOptions(command, args, getenv, config, pipelines[i])
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, pipelineOptions)
+	}
+
+	return options, nil
+}
+
+// parsePipelineOptions reads the options of one pipeline, which is either the name of
+// a pipeline in the config file or a from:to pair that uses the top level settings.
+func parsePipelineOptions(command string, args []string, getenv func(string) string, config *Config, pipeline string) (Options, error) {
+	f, err := newOptionFlags(command, args)
+	if err != nil {
+		return Options{}, err
+	}
+	if err := applyEnv(f.flags, getenv); err != nil {
+		return Options{}, err
+	}
+
+	configPipeline := pipeline
+	if from, to, ok := strings.Cut(pipeline, ":"); ok {
+		if from == "" || to == "" {
+			return Options{}, fmt.Errorf("invalid pipeline %q: must be a pipeline name or from:to", pipeline)
+		}
+		f.flags.Set("from", from)
+		f.flags.Set("to", to)
+		configPipeline = ""
+	} else if config == nil {
+		return Options{}, fmt.Errorf("pipeline %q is not defined: no config file was found", pipeline)
+	}
+
+	if config != nil {
+		if err := config.apply(f.flags, configPipeline); err != nil {
+			return Options{}, err
+		}
+	}
+
+	return f.options(pipeline, getenv)
+}
+
 type Result struct {
+	// Set when pipelines are run. Error is then the error of this pipeline.
+	Pipeline string `json:"pipeline,omitempty"`
+	Error    string `json:"error,omitempty"`
+
 	IsCreated          bool                `json:"is_created,omitempty"`
 	ReleasePullRequest *github.PullRequest `json:"release_pull_request,omitempty"`
+	// What was done to the release pull request: created, overwritten, appended or unchanged.
+	Action string `json:"action,omitempty"`
+
+	// Set by --dry-run. IsCreated then tells whether a pull request would be created,
+	// and ReleasePullRequest is the pull request that would be updated.
+	DryRun      bool     `json:"dry_run,omitempty"`
+	Title       string   `json:"title,omitempty"`
+	Body        string   `json:"body,omitempty"`
+	AddedLabels []string `json:"added_labels,omitempty"`
+
+	// The reviewers and team reviewers requested by this run, or by --dry-run.
+	RequestedReviewers     []string `json:"requested_reviewers,omitempty"`
+	RequestedTeamReviewers []string `json:"requested_team_reviewers,omitempty"`
+	// The assignees added by this run, or by --dry-run.
+	AddedAssignees []string `json:"added_assignees,omitempty"`
+
+	// Set by --suggest-version.
+	PreviousVersion string `json:"previous_version,omitempty"`
+	NextVersion     string `json:"next_version,omitempty"`
+
+	// Set by list.
+	PullRequests         []github.PullRequest  `json:"pull_requests,omitempty"`
+	ExcludedPullRequests []ExcludedPullRequest `json:"excluded_pull_requests,omitempty"`
+
+	// The tracker keys whose issues --tracker could not update. They do not fail the run.
+	TrackerFailures []TrackerFailure `json:"tracker_failures,omitempty"`
+
+	// Set by publish. ReleasePullRequest is then the merged release pull request.
+	Tag     string                    `json:"tag,omitempty"`
+	Release *github.RepositoryRelease `json:"release,omitempty"`
 }
 
 func getResultJson(result Result) (string, error) {
@@ -94,69 +450,333 @@ func getResultJson(result Result) (string, error) {
 	return string(resultJson), nil
 }
 
-func exitWithError(err error) {
-	fmt.Fprintln(os.Stderr, "Error: ", err)
-	os.Exit(1)
+func getResultsJson(results []Result) (string, error) {
+	resultsJson, err := json.Marshal(results)
+	if err != nil {
+		return "", err
+	}
+	return string(resultsJson), nil
 }
 
-func run(options Options) (*Result, error) {
-	logger = GetLogger()
-	logger.Printf("version: %s, commit: %s, date: %s\n", version, commit, date)
-
-	ctx := context.Background()
-
-	from := options.from
-	to := options.to
-
-	client := NewClient(GithubClientOptions{owner: options.owner, repo: options.repo, githubToken: options.gitHubToken, apiUrl: options.apiUrl})
+func fetchReleasePullRequests(ctx context.Context, client *GithubClient, options Options) ([]github.PullRequest, error) {
+	if options.source == sourceGithub && options.api == "graphql" {
+		return client.FetchReleasePullRequestsGraphQL(ctx, options.from, options.to)
+	}
 
-	prNumbers, err := client.FetchPullRequestNumbers(ctx, from, to)
+	var prNumbers []int
+	var err error
+	if options.source == sourceLocalGit {
+		prNumbers, err = fetchLocalPullRequestNumbers(options.gitDir, options.from, options.to)
+	} else {
+		prNumbers, err = client.FetchPullRequestNumbers(ctx, options.from, options.to)
+	}
 	if err != nil {
 		return nil, err
 	}
 
 	if len(prNumbers) == 0 {
-		logger.Println("No pull requests were found for the release. Nothing to do.")
 		return nil, nil
 	}
 
 	logger.Println("Found pull requests: ", prNumbers)
 
-	pullRequests, err := client.FetchPullRequests(ctx, prNumbers)
+	return client.FetchPullRequests(ctx, prNumbers)
+}
 
-	if err != nil {
-		return nil, err
+// dryRun reports what run would do to pr, the existing release pull request or nil, without doing it.
+func dryRun(ctx context.Context, client *GithubClient, options Options, pr *github.PullRequest, pullRequests []github.PullRequest, title, body, action string) (*Result, error) {
+	var err error
+
+	switch action {
+	case actionCreated:
+		logger.Println("Dry run: a new pull request would be created.")
+	case actionUnchanged:
+		logger.Println("Dry run: the existing pull request is up to date and would not be updated.", pr.GetNumber())
+	default:
+		logger.Println("Dry run: the existing pull request would be updated.", pr.GetNumber())
+	}
+
+	labels := missingLabels(pr, options.labels)
+	if len(labels) > 0 {
+		logger.Println("Dry run: labels would be added:", strings.Join(labels, ", "))
+	}
+
+	reviewers := releaseReviewers(options, pullRequests, pr)
+	teamReviewers := options.teamReviewers
+	if pr != nil && (len(reviewers) > 0 || len(teamReviewers) > 0) {
+		reviewers, teamReviewers, err = client.PendingReviewers(ctx, pr.GetNumber(), reviewers, teamReviewers)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(reviewers) > 0 || len(teamReviewers) > 0 {
+		logger.Println("Dry run: reviews would be requested from:", strings.Join(slices.Concat(reviewers, teamReviewers), ", "))
+	}
+
+	assignees := releaseAssignees(options, pullRequests, pr)
+	if len(assignees) > 0 {
+		logger.Println("Dry run: assignees would be added:", strings.Join(assignees, ", "))
+	}
+
+	result := Result{
+		IsCreated:              pr == nil,
+		ReleasePullRequest:     pr,
+		Action:                 action,
+		DryRun:                 true,
+		Title:                  title,
+		Body:                   body,
+		AddedLabels:            labels,
+		RequestedReviewers:     reviewers,
+		RequestedTeamReviewers: teamReviewers,
+		AddedAssignees:         assignees,
+	}
+
+	return &result, nil
+}
+
+// missingLabels returns the labels that pr does not have yet.
+func missingLabels(pr *github.PullRequest, labels []string) []string {
+	var current []*github.Label
+	if pr != nil {
+		current = pr.Labels
+	}
+
+	missing := []string{}
+	for i := 0; i < len(labels); i++ {
+		if !slices.ContainsFunc(current, func(label *github.Label) bool { return label.GetName() == labels[i] }) {
+			missing = append(missing, labels[i])
+		}
+	}
+	return missing
+}
+
+// releaseReviewers returns the users to request reviews from: --reviewers, followed by the
+// authors of the included pull requests with --request-review-from-authors. The author of the
+// release pull request is left out because GitHub does not allow them to review it.
+func releaseReviewers(options Options, pullRequests []github.PullRequest, releasePullRequest *github.PullRequest) []string {
+	reviewers := slices.Clone(options.reviewers)
+	if options.requestReviewFromAuthors {
+		reviewers = append(reviewers, pullRequestAuthors(pullRequests, options.botLogins)...)
+	}
+
+	if releasePullRequest != nil {
+		reviewers = excludeLogins(reviewers, []string{releasePullRequest.GetUser().GetLogin()})
+	}
+
+	return uniqueLogins(reviewers)
+}
+
+// releaseAssignees returns the users to assign the release pull request to: --assignees, followed by
+// the authors of the included pull requests with --assign-authors. Users already assigned are left out.
+func releaseAssignees(options Options, pullRequests []github.PullRequest, releasePullRequest *github.PullRequest) []string {
+	assignees := slices.Clone(options.assignees)
+	if options.assignAuthors {
+		assignees = append(assignees, pullRequestAuthors(pullRequests, options.botLogins)...)
+	}
+
+	if releasePullRequest != nil {
+		current := []string{}
+		for i := 0; i < len(releasePullRequest.Assignees); i++ {
+			current = append(current, releasePullRequest.Assignees[i].GetLogin())
+		}
+		assignees = excludeLogins(assignees, current)
+	}
+
+	return uniqueLogins(assignees)
+}
+
+// uniqueLogins returns logins without duplicates, keeping the first of each.
+// Logins are compared case-insensitively, as GitHub does.
+func uniqueLogins(logins []string) []string {
+	unique := []string{}
+	for i := 0; i < len(logins); i++ {
+		if !slices.ContainsFunc(unique, func(login string) bool { return strings.EqualFold(login, logins[i]) }) {
+			unique = append(unique, logins[i])
+		}
+	}
+	return unique
+}
+
+// pullRequestAuthors returns the logins of the authors of pullRequests in order, without bots and duplicates.
+func pullRequestAuthors(pullRequests []github.PullRequest, botLogins []string) []string {
+	authors := []string{}
+	for i := 0; i < len(pullRequests); i++ {
+		user := pullRequests[i].GetUser()
+		if user.GetLogin() == "" || isBot(user, botLogins) || slices.Contains(authors, user.GetLogin()) {
+			continue
+		}
+		authors = append(authors, user.GetLogin())
+	}
+	return authors
+}
+
+// isBot reports whether user is a GitHub App or bot account, like dependabot[bot], or is in botLogins.
+// An entry of botLogins that starts with * matches the end of the login.
+func isBot(user *github.User, botLogins []string) bool {
+	if user.GetType() == "Bot" || strings.HasSuffix(user.GetLogin(), "[bot]") {
+		return true
+	}
+
+	return matchesLogin(user.GetLogin(), botLogins)
+}
+
+// matchesLogin reports whether login is one of patterns, ignoring case. A leading * matches a suffix.
+func matchesLogin(login string, patterns []string) bool {
+	login = strings.ToLower(login)
+	for i := 0; i < len(patterns); i++ {
+		pattern := strings.ToLower(patterns[i])
+		if suffix, ok := strings.CutPrefix(pattern, "*"); (ok && strings.HasSuffix(login, suffix)) || pattern == login {
+			return true
+		}
+	}
+	return false
+}
+
+// excludeReleasePullRequests leaves the pull requests excluded by the options out of pullRequests.
+func excludeReleasePullRequests(pullRequests []github.PullRequest, options Options) ([]github.PullRequest, []ExcludedPullRequest) {
+	included, excluded := excludePullRequests(pullRequests, newPullRequestFilter(options))
+	for i := 0; i < len(excluded); i++ {
+		logger.Printf("Excluded the pull request #%d: %s\n", excluded[i].GetNumber(), excluded[i].Reason)
+	}
+	return included, excluded
+}
+
+// renderRelease renders the template for pullRequests and returns the title, the body and the data they were rendered from.
+// excluded are the pull requests left out of the release, and manualSection is the manual section
+// of the current release pull request, if any. releaseSha is the commit being published, whose tags
+// are not the previous version, or "" before the release pull request is merged.
+func renderRelease(ctx context.Context, client *GithubClient, options Options, pullRequests []github.PullRequest, excluded []ExcludedPullRequest, manualSection string, releaseSha string) (string, string, RenderTemplateData, error) {
+	templatePullRequests := newTemplatePullRequests(pullRequests)
+	if options.linkedIssues {
+		linkedIssues, err := fetchLinkedIssues(ctx, client, pullRequests)
+		if err != nil {
+			return "", "", RenderTemplateData{}, err
+		}
+		for i := 0; i < len(templatePullRequests); i++ {
+			templatePullRequests[i].LinkedIssues = linkedIssues[i]
+		}
+	}
+	if options.trackerKeyPattern != nil {
+		finder := trackerKeyFinder{pattern: options.trackerKeyPattern, url: options.trackerUrl}
+		for i := 0; i < len(templatePullRequests); i++ {
+			templatePullRequests[i].TrackerKeys = finder.find(templatePullRequests[i])
+		}
 	}
 
 	currentTime := time.Now()
 	date := currentTime.Format("2006-01-02")
 	renderTemplateData := RenderTemplateData{
-		PullRequests:     pullRequests,
+		PullRequests:     templatePullRequests,
+		Sections:         groupSections(templatePullRequests, options.sections, options.otherSectionTitle),
+		PullRequestTree:  buildPullRequestTree(templatePullRequests),
+		Issues:           collectIssues(templatePullRequests),
+		TrackerKeys:      collectTrackerKeys(templatePullRequests),
+		Features:         filterPullRequests(templatePullRequests, func(pr TemplatePullRequest) bool { return pr.Type == "feat" }),
+		Fixes:            filterPullRequests(templatePullRequests, func(pr TemplatePullRequest) bool { return pr.Type == "fix" }),
+		BreakingChanges:  filterPullRequests(templatePullRequests, func(pr TemplatePullRequest) bool { return pr.Breaking }),
+		Excluded:         excluded,
 		Date:             date,
-		From:             from,
-		To:               to,
+		From:             options.from,
+		To:               options.to,
 		CustomParameters: options.customParameters,
+		ManualSection:    manualSection,
+	}
+	if manualSection == "" {
+		renderTemplateData.ManualSection = emptyManualSection()
 	}
+	if options.suggestVersion {
+		previousVersion, err := client.FindLatestVersionTag(ctx, options.to, releaseSha)
+		if err != nil {
+			return "", "", RenderTemplateData{}, err
+		}
+		renderTemplateData.PreviousVersion = previousVersion
+		renderTemplateData.NextVersion = suggestVersion(previousVersion, templatePullRequests, options.majorLabels, options.minorLabels)
+		logger.Printf("Suggested version: %s (previous: %s)\n", renderTemplateData.NextVersion, renderTemplateData.PreviousVersion)
+	}
+
 	data, err := RenderTemplate(options.template, renderTemplateData, options.disableGeneratedByMessage)
+	if err != nil {
+		return "", "", RenderTemplateData{}, err
+	}
+
+	parts := strings.SplitN(data, "\n", 2)
+	if len(parts) < 2 {
+		parts = append(parts, "")
+	}
+
+	return parts[0], parts[1], renderTemplateData, nil
+}
+
+func run(options Options) (*Result, error) {
+	logger = GetLogger()
+	logger.Printf("version: %s, commit: %s, date: %s\n", version, commit, date)
+
+	ctx := context.Background()
+
+	from := options.from
+	to := options.to
 
+	client := NewClient(GithubClientOptions{owner: options.owner, repo: options.repo, githubToken: options.gitHubToken, apiUrl: options.apiUrl, concurrency: options.concurrency, maxRetries: options.maxRetries})
+
+	pullRequests, err := fetchReleasePullRequests(ctx, client, options)
 	if err != nil {
 		return nil, err
 	}
 
-	parts := strings.SplitN(data, "\n", 2)
-	title := parts[0]
-	body := parts[1]
+	pullRequests, excluded := excludeReleasePullRequests(pullRequests, options)
 
-	logger.Println("Title of pull request:  ", title)
+	if len(pullRequests) == 0 {
+		logger.Println("No pull requests were found for the release. Nothing to do.")
+		return nil, nil
+	}
 
-	pr, created, err := client.CreatePullRequest(ctx, title, body, from, to)
+	existing, err := client.FindPullRequest(ctx, from, to)
 	if err != nil {
 		return nil, err
 	}
 
-	if created {
+	manualSection := extractManualSection(existing.GetBody())
+
+	title, body, renderTemplateData, err := renderRelease(ctx, client, options, pullRequests, excluded, manualSection, "")
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Println("Title of pull request:  ", title)
+
+	action := actionCreated
+	if existing != nil {
+		body = preserveCheckedItems(existing.GetBody(), body)
+		body = restoreManualSection(body, manualSection)
+		title, body, action = applyUpdateStrategy(options.updateStrategy, existing, title, body)
+	}
+
+	if options.dryRun {
+		result, err := dryRun(ctx, client, options, existing, pullRequests, title, body, action)
+		if err != nil {
+			return nil, err
+		}
+		result.PreviousVersion, result.NextVersion = renderTemplateData.PreviousVersion, renderTemplateData.NextVersion
+		return result, nil
+	}
+
+	pr, created := existing, false
+	if pr == nil {
+		pr, created, err = client.CreatePullRequest(ctx, title, body, from, to)
+		if err != nil {
+			return nil, err
+		}
+		if !created {
+			action = actionOverwritten
+		}
+	}
+
+	switch {
+	case created:
 		logger.Println("Created new a pull request.", pr.GetNumber())
-	} else {
+	case action == actionUnchanged:
+		logger.Println("The pull request already exists and is up to date. It was not updated.", pr.GetNumber())
+	default:
 		_, err := client.UpdatePullRequest(ctx, pr.GetNumber(), title, body)
 		if err != nil {
 			return nil, err
@@ -172,36 +792,42 @@ func run(options Options) (*Result, error) {
 		logger.Println("Added labels to the pull request.", pr.GetNumber())
 	}
 
-	result := Result{IsCreated: created, ReleasePullRequest: pr}
-
-	return &result, nil
-}
-
-func main() {
-	options, err := getOptions()
-
-	if err != nil {
-		exitWithError(err)
-	}
-
-	result, err := run(options)
-
-	if err != nil {
-		exitWithError(err)
+	result := Result{
+		IsCreated:          created,
+		ReleasePullRequest: pr,
+		Action:             action,
+		PreviousVersion:    renderTemplateData.PreviousVersion,
+		NextVersion:        renderTemplateData.NextVersion,
 	}
 
-	if options.json {
-
-		if result == nil {
-			result = &Result{}
+	assignees := releaseAssignees(options, pullRequests, pr)
+	if len(assignees) > 0 {
+		err := client.AddAssigneesToPullRequest(ctx, pr.GetNumber(), assignees)
+		if err != nil {
+			return nil, err
 		}
+		result.AddedAssignees = assignees
+		logger.Println("Added assignees to the pull request.", pr.GetNumber())
+	}
 
-		resultJson, err := getResultJson(*result)
-
+	reviewers := releaseReviewers(options, pullRequests, pr)
+	if len(reviewers) > 0 || len(options.teamReviewers) > 0 {
+		result.RequestedReviewers, result.RequestedTeamReviewers, err = client.RequestReviewers(ctx, pr.GetNumber(), reviewers, options.teamReviewers)
 		if err != nil {
-			exitWithError(err)
+			return nil, err
+		}
+		if len(result.RequestedReviewers) > 0 || len(result.RequestedTeamReviewers) > 0 {
+			logger.Println("Requested reviews on the pull request.", pr.GetNumber())
 		}
+	}
 
-		fmt.Println(resultJson)
+	if options.tracker != "" && len(renderTemplateData.TrackerKeys) > 0 {
+		result.TrackerFailures = updateTracker(ctx, newTracker(options), options, renderTemplateData.TrackerKeys, pr)
 	}
+
+	return &result, nil
+}
+
+func main() {
+	os.Exit(runCLI(os.Args[1:], os.Stdout, os.Stderr, os.Getenv))
 }
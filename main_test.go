@@ -1,8 +1,16 @@
 package main
 
 import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
 	"testing"
+	"time"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-github/v60/github"
 )
 
@@ -20,3 +28,279 @@ func TestGetResultJson(t *testing.T) {
 		t.Errorf("outputResult returned %v, want %v", resultJson, want)
 	}
 }
+
+func TestGetResultsJson(t *testing.T) {
+	results := []Result{
+		{Pipeline: "staging", IsCreated: true, ReleasePullRequest: &github.PullRequest{Number: github.Int(1)}},
+		{Pipeline: "production", Error: "not found"},
+	}
+
+	resultsJson, err := getResultsJson(results)
+
+	if err != nil {
+		t.Errorf("getResultsJson returned error: %v", err)
+	}
+
+	want := `[{"pipeline":"staging","is_created":true,"release_pull_request":{"number":1}},{"pipeline":"production","error":"not found"}]`
+	if resultsJson != want {
+		t.Errorf("getResultsJson returned %v, want %v", resultsJson, want)
+	}
+}
+
+func TestMissingLabels(t *testing.T) {
+	pr := &github.PullRequest{Labels: []*github.Label{{Name: github.String("release")}}}
+
+	got := missingLabels(pr, []string{"release", "production"})
+	want := []string{"production"}
+	if !cmp.Equal(got, want) {
+		t.Errorf("missingLabels returned %v, want %v", got, want)
+	}
+
+	got = missingLabels(nil, []string{"release"})
+	want = []string{"release"}
+	if !cmp.Equal(got, want) {
+		t.Errorf("missingLabels returned %v, want %v", got, want)
+	}
+}
+
+func TestReleaseReviewers(t *testing.T) {
+	pullRequests := []github.PullRequest{
+		{Number: github.Int(1), User: &github.User{Login: github.String("alice")}},
+		{Number: github.Int(2), User: &github.User{Login: github.String("dependabot[bot]"), Type: github.String("Bot")}},
+		{Number: github.Int(3), User: &github.User{Login: github.String("bob")}},
+		{Number: github.Int(4), User: &github.User{Login: github.String("alice")}},
+		{Number: github.Int(5), User: &github.User{Login: github.String("release-manager")}},
+	}
+	releasePullRequest := &github.PullRequest{User: &github.User{Login: github.String("release-manager")}}
+
+	tests := []struct {
+		name    string
+		options Options
+		want    []string
+	}{
+		{
+			name:    "reviewers",
+			options: Options{reviewers: []string{"carol"}},
+			want:    []string{"carol"},
+		},
+		{
+			name:    "authors",
+			options: Options{reviewers: []string{"carol"}, requestReviewFromAuthors: true},
+			want:    []string{"carol", "alice", "bob"},
+		},
+		{
+			name:    "reviewer who is also an author",
+			options: Options{reviewers: []string{"Alice", "carol"}, requestReviewFromAuthors: true},
+			want:    []string{"Alice", "carol", "bob"},
+		},
+		{
+			name:    "release pull request author",
+			options: Options{reviewers: []string{"Release-Manager"}},
+			want:    []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := releaseReviewers(tt.options, pullRequests, releasePullRequest)
+			if !cmp.Equal(got, tt.want) {
+				t.Errorf("releaseReviewers returned %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReleaseAssignees(t *testing.T) {
+	pullRequests := []github.PullRequest{
+		{Number: github.Int(1), User: &github.User{Login: github.String("alice")}},
+		{Number: github.Int(2), User: &github.User{Login: github.String("renovate[bot]")}},
+		{Number: github.Int(3), User: &github.User{Login: github.String("deploy-bot")}},
+		{Number: github.Int(4), User: &github.User{Login: github.String("ci-user")}},
+		{Number: github.Int(5), User: &github.User{Login: github.String("bob")}},
+	}
+	releasePullRequest := &github.PullRequest{Assignees: []*github.User{{Login: github.String("bob")}}}
+
+	options := Options{assignees: []string{"carol"}, assignAuthors: true, botLogins: []string{"*-bot", "CI-User"}}
+	got := releaseAssignees(options, pullRequests, releasePullRequest)
+
+	want := []string{"carol", "alice"}
+	if !cmp.Equal(got, want) {
+		t.Errorf("releaseAssignees returned %v, want %v", got, want)
+	}
+	// An assignee who is also an author is assigned once.
+	options = Options{assignees: []string{"ALICE", "carol"}, assignAuthors: true}
+	got = releaseAssignees(options, pullRequests[:1], nil)
+
+	want = []string{"ALICE", "carol"}
+	if !cmp.Equal(got, want) {
+		t.Errorf("releaseAssignees returned %v, want %v", got, want)
+	}
+}
+
+func TestRun_dryRun(t *testing.T) {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc(
+		"/repos/owner/repo/compare/to...from",
+		func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"commits": [{"sha": "sha1"}]}`)
+		},
+	)
+	mux.HandleFunc(
+		"/repos/owner/repo/commits/sha1/pulls",
+		func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `[{"number": 1}]`)
+		},
+	)
+	mux.HandleFunc(
+		"/repos/owner/repo/pulls/1",
+		func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"number": 1, "merged_at": "2021-01-01T00:00:00Z", "user": {"login": "carol"}}`)
+		},
+	)
+	mux.HandleFunc(
+		"/repos/owner/repo/pulls/10/requested_reviewers",
+		func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"users": [{"login": "alice"}], "teams": []}`)
+		},
+	)
+	mux.HandleFunc(
+		"/repos/owner/repo/pulls/10/reviews",
+		func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `[]`)
+		},
+	)
+	mux.HandleFunc(
+		"/repos/owner/repo/pulls",
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != "GET" {
+				t.Errorf("dry run must not call %v %v", r.Method, r.URL.Path)
+			}
+			fmt.Fprint(w, `[{"number": 10, "labels": [{"name": "release"}]}]`)
+		},
+	)
+	mux.HandleFunc(
+		"/repos/owner/repo/issues/10/labels",
+		func(w http.ResponseWriter, r *http.Request) {
+			t.Errorf("dry run must not call %v %v", r.Method, r.URL.Path)
+		},
+	)
+
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	apiUrl, _ := url.Parse(ts.URL)
+	result, err := run(Options{
+		from:                      "from",
+		to:                        "to",
+		labels:                    []string{"release", "production"},
+		disableGeneratedByMessage: true,
+		dryRun:                    true,
+		reviewers:                 []string{"alice", "bob"},
+		teamReviewers:             []string{"qa"},
+		requestReviewFromAuthors:  true,
+		assignees:                 []string{"dave"},
+		assignAuthors:             true,
+		owner:                     "owner",
+		repo:                      "repo",
+		apiUrl:                    apiUrl,
+	})
+
+	if err != nil {
+		t.Fatalf("run returned error: %v", err)
+	}
+
+	want := &Result{
+		IsCreated:              false,
+		ReleasePullRequest:     &github.PullRequest{Number: github.Int(10), Labels: []*github.Label{{Name: github.String("release")}}},
+		Action:                 actionOverwritten,
+		DryRun:                 true,
+		Title:                  "Release " + time.Now().Format("2006-01-02"),
+		Body:                   "# PRs\n- #1\n",
+		AddedLabels:            []string{"production"},
+		RequestedReviewers:     []string{"bob", "carol"},
+		RequestedTeamReviewers: []string{"qa"},
+		AddedAssignees:         []string{"dave", "carol"},
+	}
+	if !cmp.Equal(result, want) {
+		t.Errorf("run returned %+v, want %+v", result, want)
+	}
+}
+
+func TestRun_preservesCheckedItems(t *testing.T) {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc(
+		"/repos/owner/repo/compare/to...from",
+		func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"commits": [{"sha": "sha1"}, {"sha": "sha2"}]}`)
+		},
+	)
+	mux.HandleFunc(
+		"/repos/owner/repo/commits/sha1/pulls",
+		func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `[{"number": 1}]`)
+		},
+	)
+	mux.HandleFunc(
+		"/repos/owner/repo/commits/sha2/pulls",
+		func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `[{"number": 2}]`)
+		},
+	)
+	mux.HandleFunc(
+		"/repos/owner/repo/pulls/1",
+		func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"number": 1, "merged_at": "2021-01-01T00:00:00Z"}`)
+		},
+	)
+	mux.HandleFunc(
+		"/repos/owner/repo/pulls/2",
+		func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"number": 2, "merged_at": "2021-01-02T00:00:00Z"}`)
+		},
+	)
+	mux.HandleFunc(
+		"/repos/owner/repo/pulls",
+		func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `[{"number": 10, "body": "# PRs\r\n- [x] #1\r\n"}]`)
+		},
+	)
+
+	var updatedBody string
+	mux.HandleFunc(
+		"/repos/owner/repo/pulls/10",
+		func(w http.ResponseWriter, r *http.Request) {
+			var pr github.PullRequest
+			json.NewDecoder(r.Body).Decode(&pr)
+			updatedBody = pr.GetBody()
+			fmt.Fprint(w, `{"number": 10}`)
+		},
+	)
+
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	template := makeDummyTemplate("Release\n# PRs\n{{#pull_requests}}\n- [ ] #{{number}}\n{{/pull_requests}}\n")
+	defer os.Remove(template)
+
+	apiUrl, _ := url.Parse(ts.URL)
+	_, err := run(Options{
+		from:                      "from",
+		to:                        "to",
+		template:                  &template,
+		disableGeneratedByMessage: true,
+		owner:                     "owner",
+		repo:                      "repo",
+		apiUrl:                    apiUrl,
+	})
+
+	if err != nil {
+		t.Fatalf("run returned error: %v", err)
+	}
+
+	want := "# PRs\n- [x] #1\n- [ ] #2\n"
+	if updatedBody != want {
+		t.Errorf("run updated the body to %q, want %q", updatedBody, want)
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// publish tags the merge commit of the most recently merged release pull request and creates
+// a GitHub Release for it, with notes rendered from the same template as the release pull request.
+func publish(options Options) (*Result, error) {
+	logger = GetLogger()
+	logger.Printf("version: %s, commit: %s, date: %s\n", version, commit, date)
+
+	if options.tag == "" && !options.suggestVersion {
+		return nil, errors.New("publish needs --tag or --suggest-version to name the release")
+	}
+
+	ctx := context.Background()
+
+	client := NewClient(GithubClientOptions{owner: options.owner, repo: options.repo, githubToken: options.gitHubToken, apiUrl: options.apiUrl, concurrency: options.concurrency, maxRetries: options.maxRetries})
+
+	pr, err := client.FindMergedPullRequest(ctx, options.from, options.to)
+	if err != nil {
+		return nil, err
+	}
+	if pr == nil {
+		return nil, fmt.Errorf("no merged pull request from %s into %s was found", options.from, options.to)
+	}
+	logger.Println("Found the merged release pull request.", pr.GetNumber())
+	sha := pr.GetMergeCommitSHA()
+
+	// A run for a later push to --to finds the same release pull request, and a failed run may be
+	// retried after the tag was created. The tag of the merge commit is the tag of the release then.
+	tag := options.tag
+	if tag == "" {
+		tag, err = client.FindVersionTagOn(ctx, sha)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if tag != "" {
+		release, err := client.FindRelease(ctx, tag)
+		if err != nil {
+			return nil, err
+		}
+		if release != nil {
+			logger.Printf("The release pull request #%d was already published as %s. Nothing to do.\n", pr.GetNumber(), tag)
+			return &Result{ReleasePullRequest: pr, Tag: tag, Release: release}, nil
+		}
+	}
+
+	pullRequests, err := fetchPublishedPullRequests(ctx, client, pr)
+	if err != nil {
+		return nil, err
+	}
+	pullRequests, excluded := excludeReleasePullRequests(pullRequests, options)
+
+	title, body, renderTemplateData, err := renderRelease(ctx, client, options, pullRequests, excluded, extractManualSection(pr.GetBody()), sha)
+	if err != nil {
+		return nil, err
+	}
+
+	if tag == "" {
+		tag = renderTemplateData.NextVersion
+	}
+
+	result := Result{
+		ReleasePullRequest: pr,
+		Title:              title,
+		Body:               body,
+		Tag:                tag,
+		PreviousVersion:    renderTemplateData.PreviousVersion,
+		NextVersion:        renderTemplateData.NextVersion,
+	}
+
+	if options.dryRun {
+		logger.Printf("Dry run: the tag %s would be created on %s and a release would be created for it.\n", tag, sha)
+		result.DryRun = true
+		return &result, nil
+	}
+
+	err = client.CreateTag(ctx, tag, sha)
+	if err != nil {
+		return nil, err
+	}
+	logger.Printf("Created the tag %s on %s.\n", tag, sha)
+
+	release, err := client.CreateRelease(ctx, &github.RepositoryRelease{
+		TagName:    github.String(tag),
+		Name:       github.String(title),
+		Body:       github.String(body),
+		Draft:      github.Bool(options.draft),
+		Prerelease: github.Bool(options.prerelease),
+	})
+	if err != nil {
+		return nil, err
+	}
+	logger.Println("Created the release.", release.GetHTMLURL())
+
+	result.Release = release
+	return &result, nil
+}
+
+// fetchPublishedPullRequests returns the pull requests that were released by the merged release pull request pr.
+// They are found from the commits of pr, since its head branch is now part of its base branch.
+func fetchPublishedPullRequests(ctx context.Context, client *GithubClient, pr *github.PullRequest) ([]github.PullRequest, error) {
+	prNumbers, err := client.FetchPullRequestNumbers(ctx, pr.GetHead().GetSHA(), pr.GetBase().GetSHA())
+	if err != nil {
+		return nil, err
+	}
+
+	// The release pull request contains the same commits.
+	included := []int{}
+	for i := 0; i < len(prNumbers); i++ {
+		if prNumbers[i] != pr.GetNumber() {
+			included = append(included, prNumbers[i])
+		}
+	}
+	logger.Println("Found pull requests: ", included)
+
+	return client.FetchPullRequests(ctx, included)
+}
@@ -0,0 +1,329 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/google/go-github/v60/github"
+)
+
+func TestPublish(t *testing.T) {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc(
+		"/repos/owner/repo/pulls",
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("state") != "closed" {
+				t.Errorf("publish listed pull requests with state %v, want %v", r.URL.Query().Get("state"), "closed")
+			}
+			fmt.Fprint(w, `[
+				{"number": 9, "merged_at": "2021-01-01T00:00:00Z"},
+				{"number": 11},
+				{"number": 10, "merged_at": "2021-01-08T00:00:00Z", "merge_commit_sha": "merge10",
+				 "head": {"sha": "head10"}, "base": {"sha": "base10"}, "body": "# PRs\n- #1\n"}
+			]`)
+		},
+	)
+	mux.HandleFunc(
+		"/repos/owner/repo/compare/base10...head10",
+		func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"commits": [{"sha": "sha1"}]}`)
+		},
+	)
+	mux.HandleFunc(
+		"/repos/owner/repo/commits/sha1/pulls",
+		func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `[{"number": 1}, {"number": 10}]`)
+		},
+	)
+	mux.HandleFunc(
+		"/repos/owner/repo/pulls/1",
+		func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"number": 1, "title": "feat: add v2", "merged_at": "2021-01-02T00:00:00Z"}`)
+		},
+	)
+	mux.HandleFunc(
+		"/repos/owner/repo/git/ref/tags/v1.0.0",
+		func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, `{"message": "Not Found"}`, http.StatusNotFound)
+		},
+	)
+
+	var createdRef struct {
+		Ref string `json:"ref"`
+		SHA string `json:"sha"`
+	}
+	mux.HandleFunc(
+		"/repos/owner/repo/git/refs",
+		func(w http.ResponseWriter, r *http.Request) {
+			json.NewDecoder(r.Body).Decode(&createdRef)
+			fmt.Fprint(w, `{"ref": "refs/tags/v1.0.0"}`)
+		},
+	)
+
+	var createdRelease github.RepositoryRelease
+	mux.HandleFunc(
+		"/repos/owner/repo/releases",
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == "GET" {
+				fmt.Fprint(w, `[]`)
+				return
+			}
+			json.NewDecoder(r.Body).Decode(&createdRelease)
+			fmt.Fprint(w, `{"id": 1, "tag_name": "v1.0.0"}`)
+		},
+	)
+
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	template := makeDummyTemplate("Release {{date}}\n{{#features}}\n- #{{number}} {{description}}\n{{/features}}\n")
+	defer os.Remove(template)
+
+	apiUrl, _ := url.Parse(ts.URL)
+	result, err := publish(Options{
+		from:                      "from",
+		to:                        "to",
+		template:                  &template,
+		disableGeneratedByMessage: true,
+		tag:                       "v1.0.0",
+		prerelease:                true,
+		owner:                     "owner",
+		repo:                      "repo",
+		apiUrl:                    apiUrl,
+	})
+
+	if err != nil {
+		t.Fatalf("publish returned error: %v", err)
+	}
+
+	if createdRef.Ref != "refs/tags/v1.0.0" || createdRef.SHA != "merge10" {
+		t.Errorf("publish created the ref %v on %v, want %v on %v", createdRef.Ref, createdRef.SHA, "refs/tags/v1.0.0", "merge10")
+	}
+	if createdRelease.GetTagName() != "v1.0.0" || !createdRelease.GetPrerelease() || createdRelease.GetDraft() {
+		t.Errorf("publish created the release %+v, want a prerelease of v1.0.0", createdRelease)
+	}
+	if createdRelease.GetBody() != "- #1 add v2\n" {
+		t.Errorf("publish created the release with the notes %q, want %q", createdRelease.GetBody(), "- #1 add v2\n")
+	}
+	if result.ReleasePullRequest.GetNumber() != 10 || result.Tag != "v1.0.0" || result.Release.GetID() != 1 {
+		t.Errorf("publish returned %+v, want the release of pull request 10", result)
+	}
+}
+
+func TestPublish_noTag(t *testing.T) {
+	_, err := publish(Options{from: "from", to: "to"})
+
+	want := "publish needs --tag or --suggest-version to name the release"
+	if err == nil || err.Error() != want {
+		t.Errorf("publish returned error %v, want %v", err, want)
+	}
+}
+
+// publishTestServer serves a merged release pull request #10 with the merge commit merge10 that released #1,
+// the tags in tags, the releases of the tags in released and the draft releases of the tags in drafts.
+type publishTestServer struct {
+	*httptest.Server
+	createdRefs     []string
+	createdReleases []string
+}
+
+func newPublishTestServer(t *testing.T, tags string, released map[string]bool, drafts map[string]bool) *publishTestServer {
+	s := &publishTestServer{}
+	mux := http.NewServeMux()
+
+	mux.HandleFunc(
+		"/repos/owner/repo/pulls",
+		func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `[{"number": 10, "merged_at": "2021-01-08T00:00:00Z", "merge_commit_sha": "merge10", "head": {"sha": "head10"}, "base": {"sha": "base10"}}]`)
+		},
+	)
+	mux.HandleFunc(
+		"/repos/owner/repo/compare/base10...head10",
+		func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"commits": [{"sha": "sha1"}]}`)
+		},
+	)
+	mux.HandleFunc(
+		"/repos/owner/repo/commits/sha1/pulls",
+		func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `[{"number": 1}]`)
+		},
+	)
+	mux.HandleFunc(
+		"/repos/owner/repo/pulls/1",
+		func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"number": 1, "title": "feat: add v2", "merged_at": "2021-01-02T00:00:00Z"}`)
+		},
+	)
+	mux.HandleFunc(
+		"/repos/owner/repo/tags",
+		func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, tags)
+		},
+	)
+	mux.HandleFunc(
+		"/repos/owner/repo/compare/v1.2.0...to",
+		func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"status": "ahead"}`)
+		},
+	)
+	mux.HandleFunc(
+		"/repos/owner/repo/compare/v1.3.0...to",
+		func(w http.ResponseWriter, r *http.Request) {
+			t.Errorf("the tag on the merge commit must not be the previous version")
+		},
+	)
+	mux.HandleFunc(
+		"/repos/owner/repo/releases/tags/",
+		func(w http.ResponseWriter, r *http.Request) {
+			tag := strings.TrimPrefix(r.URL.Path, "/repos/owner/repo/releases/tags/")
+			if !released[tag] {
+				http.Error(w, `{"message": "Not Found"}`, http.StatusNotFound)
+				return
+			}
+			fmt.Fprintf(w, `{"id": 1, "tag_name": %q}`, tag)
+		},
+	)
+	mux.HandleFunc(
+		"/repos/owner/repo/git/ref/tags/",
+		func(w http.ResponseWriter, r *http.Request) {
+			tag := strings.TrimPrefix(r.URL.Path, "/repos/owner/repo/git/ref/tags/")
+			if !strings.Contains(tags, fmt.Sprintf("%q", tag)) {
+				http.Error(w, `{"message": "Not Found"}`, http.StatusNotFound)
+				return
+			}
+			fmt.Fprintf(w, `{"ref": "refs/tags/%s", "object": {"sha": "merge10"}}`, tag)
+		},
+	)
+	mux.HandleFunc(
+		"/repos/owner/repo/git/refs",
+		func(w http.ResponseWriter, r *http.Request) {
+			var ref struct {
+				Ref string `json:"ref"`
+				SHA string `json:"sha"`
+			}
+			json.NewDecoder(r.Body).Decode(&ref)
+			s.createdRefs = append(s.createdRefs, ref.Ref+" "+ref.SHA)
+			fmt.Fprintf(w, `{"ref": %q}`, ref.Ref)
+		},
+	)
+	mux.HandleFunc(
+		"/repos/owner/repo/releases",
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == "GET" {
+				releases := []string{}
+				for tag := range drafts {
+					releases = append(releases, fmt.Sprintf(`{"id": 3, "tag_name": %q, "draft": true}`, tag))
+				}
+				fmt.Fprintf(w, "[%s]", strings.Join(releases, ","))
+				return
+			}
+			var release github.RepositoryRelease
+			json.NewDecoder(r.Body).Decode(&release)
+			s.createdReleases = append(s.createdReleases, release.GetTagName())
+			fmt.Fprintf(w, `{"id": 2, "tag_name": %q}`, release.GetTagName())
+		},
+	)
+
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+func TestPublish_suggestVersion(t *testing.T) {
+	tests := []struct {
+		name            string
+		tag             string
+		draft           bool
+		tags            string
+		released        map[string]bool
+		drafts          map[string]bool
+		wantTag         string
+		wantRefs        []string
+		wantReleases    []string
+		wantReleaseId   int64
+		wantNextVersion string
+	}{
+		{
+			name:            "first run",
+			tags:            `[{"name": "v1.2.0", "commit": {"sha": "old"}}]`,
+			wantTag:         "v1.3.0",
+			wantRefs:        []string{"refs/tags/v1.3.0 merge10"},
+			wantReleases:    []string{"v1.3.0"},
+			wantReleaseId:   2,
+			wantNextVersion: "v1.3.0",
+		},
+		{
+			name:            "retry after the tag was created",
+			tags:            `[{"name": "v1.3.0", "commit": {"sha": "merge10"}}, {"name": "v1.2.0", "commit": {"sha": "old"}}]`,
+			wantTag:         "v1.3.0",
+			wantReleases:    []string{"v1.3.0"},
+			wantReleaseId:   2,
+			wantNextVersion: "v1.3.0",
+		},
+		{
+			name:          "second run",
+			tags:          `[{"name": "v1.3.0", "commit": {"sha": "merge10"}}, {"name": "v1.2.0", "commit": {"sha": "old"}}]`,
+			released:      map[string]bool{"v1.3.0": true},
+			wantTag:       "v1.3.0",
+			wantReleaseId: 1,
+		},
+		{
+			name:          "second run with --tag",
+			tag:           "v1.3.0",
+			tags:          `[{"name": "v1.3.0", "commit": {"sha": "merge10"}}]`,
+			released:      map[string]bool{"v1.3.0": true},
+			wantTag:       "v1.3.0",
+			wantReleaseId: 1,
+		},
+		{
+			name:          "second run of a draft",
+			draft:         true,
+			tags:          `[{"name": "v1.3.0", "commit": {"sha": "merge10"}}, {"name": "v1.2.0", "commit": {"sha": "old"}}]`,
+			drafts:        map[string]bool{"v1.3.0": true},
+			wantTag:       "v1.3.0",
+			wantReleaseId: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := newPublishTestServer(t, tt.tags, tt.released, tt.drafts)
+			defer ts.Close()
+
+			apiUrl, _ := url.Parse(ts.URL)
+			result, err := publish(Options{
+				from:                      "from",
+				to:                        "to",
+				disableGeneratedByMessage: true,
+				tag:                       tt.tag,
+				suggestVersion:            tt.tag == "",
+				draft:                     tt.draft,
+				owner:                     "owner",
+				repo:                      "repo",
+				apiUrl:                    apiUrl,
+			})
+
+			if err != nil {
+				t.Fatalf("publish returned error: %v", err)
+			}
+			if !cmp.Equal(ts.createdRefs, tt.wantRefs, cmpopts.EquateEmpty()) {
+				t.Errorf("publish created the refs %v, want %v", ts.createdRefs, tt.wantRefs)
+			}
+			if !cmp.Equal(ts.createdReleases, tt.wantReleases, cmpopts.EquateEmpty()) {
+				t.Errorf("publish created the releases %v, want %v", ts.createdReleases, tt.wantReleases)
+			}
+			if result.Tag != tt.wantTag || result.Release.GetID() != tt.wantReleaseId || result.NextVersion != tt.wantNextVersion {
+				t.Errorf("publish returned the tag %v, release %v and next version %v, want %v, %v and %v", result.Tag, result.Release.GetID(), result.NextVersion, tt.wantTag, tt.wantReleaseId, tt.wantNextVersion)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// retryTransport retries requests that failed because of a rate limit or a server error.
+// Rate limited requests wait for the time GitHub asks for in Retry-After or X-RateLimit-Reset,
+// server errors back off exponentially with jitter. A retry is never scheduled past the
+// deadline of the request context.
+type retryTransport struct {
+	base       http.RoundTripper
+	maxRetries int
+	// baseDelay is the delay before the first retry of a server error. It doubles on every attempt.
+	baseDelay time.Duration
+	// maxDelay caps every wait, including the ones requested by GitHub.
+	maxDelay time.Duration
+	// idempotentOnly stops retrying requests that the server may already have processed,
+	// except for GET and HEAD. Others are only retried when a 429 or 503 asks to with Retry-After.
+	idempotentOnly bool
+	now            func() time.Time
+}
+
+func newRetryTransport(base http.RoundTripper, maxRetries int) *retryTransport {
+	return &retryTransport{
+		base:       base,
+		maxRetries: maxRetries,
+		baseDelay:  time.Second,
+		maxDelay:   5 * time.Minute,
+		now:        time.Now,
+	}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	for attempt := 0; ; attempt++ {
+		resp, err := t.base.RoundTrip(req)
+		if err != nil || attempt >= t.maxRetries {
+			return resp, err
+		}
+
+		delay, retry := t.retryDelay(resp, attempt)
+		if !retry || (t.idempotentOnly && !canRetry(req, resp)) {
+			return resp, nil
+		}
+
+		if deadline, ok := ctx.Deadline(); ok && t.now().Add(delay).After(deadline) {
+			return resp, nil
+		}
+
+		if req.Body != nil && req.GetBody == nil {
+			return resp, nil
+		}
+
+		GetLogger().Printf("%s %s returned %d. Retrying in %s (%d/%d).\n", req.Method, req.URL.Path, resp.StatusCode, delay.Round(time.Millisecond), attempt+1, t.maxRetries)
+		resp.Body.Close()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+
+		req, err = rewindRequest(req)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// retryDelay reports whether resp should be retried and how long to wait before doing so.
+func (t *retryTransport) retryDelay(resp *http.Response, attempt int) (time.Duration, bool) {
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusForbidden:
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			return min(time.Duration(seconds)*time.Second, t.maxDelay), true
+		}
+		if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+			if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+				return min(max(time.Unix(reset, 0).Sub(t.now()), 0), t.maxDelay), true
+			}
+		}
+		// Any other 403 is a permission problem that a retry will not fix.
+		if resp.StatusCode == http.StatusForbidden {
+			return 0, false
+		}
+	case resp.StatusCode < 500:
+		return 0, false
+	}
+
+	backoff := min(t.baseDelay<<attempt, t.maxDelay)
+	// The jitter spreads out the retries of requests that failed at the same time.
+	return backoff/2 + rand.N(backoff/2+1), true
+}
+
+type readOnlyKey struct{}
+
+// withReadOnly marks the requests made with ctx as safe to send again whatever their method,
+// like GraphQL queries, which are POSTs that only read.
+func withReadOnly(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readOnlyKey{}, true)
+}
+
+// canRetry reports whether req can be sent again after resp without the risk of
+// repeating its effect, like posting the same comment twice.
+func canRetry(req *http.Request, resp *http.Response) bool {
+	if req.Method == http.MethodGet || req.Method == http.MethodHead || req.Context().Value(readOnlyKey{}) != nil {
+		return true
+	}
+	// GitHub only retries a 403 for a rate limit, which turns the request away like a 429.
+	if resp.StatusCode == http.StatusForbidden {
+		return true
+	}
+	// A 429 or a 503 with Retry-After means that the request was turned away, not processed.
+	rejected := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable
+	return rejected && resp.Header.Get("Retry-After") != ""
+}
+
+func rewindRequest(req *http.Request) (*http.Request, error) {
+	if req.GetBody == nil {
+		return req, nil
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+
+	rewound := req.Clone(req.Context())
+	rewound.Body = body
+	return rewound, nil
+}
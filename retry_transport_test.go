@@ -0,0 +1,319 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// failingHandler answers with the given failure until it has been called failures times.
+func failingHandler(failures int32, fail func(w http.ResponseWriter), calls *atomic.Int32) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= failures {
+			fail(w)
+			return
+		}
+		fmt.Fprint(w, `{"number": 1}`)
+	}
+}
+
+func newTestRetryTransport(maxRetries int) *retryTransport {
+	transport := newRetryTransport(http.DefaultTransport, maxRetries)
+	transport.baseDelay = time.Millisecond
+	return transport
+}
+
+func TestRetryTransport(t *testing.T) {
+	tests := []struct {
+		name       string
+		failures   int32
+		maxRetries int
+		fail       func(w http.ResponseWriter)
+		wantStatus int
+		wantCalls  int32
+	}{
+		{
+			name:       "server error",
+			failures:   2,
+			maxRetries: 3,
+			fail:       func(w http.ResponseWriter) { w.WriteHeader(http.StatusBadGateway) },
+			wantStatus: http.StatusOK,
+			wantCalls:  3,
+		},
+		{
+			name:       "too many server errors",
+			failures:   5,
+			maxRetries: 2,
+			fail:       func(w http.ResponseWriter) { w.WriteHeader(http.StatusInternalServerError) },
+			wantStatus: http.StatusInternalServerError,
+			wantCalls:  3,
+		},
+		{
+			name:       "secondary rate limit",
+			failures:   1,
+			maxRetries: 3,
+			fail: func(w http.ResponseWriter) {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusForbidden)
+			},
+			wantStatus: http.StatusOK,
+			wantCalls:  2,
+		},
+		{
+			name:       "primary rate limit",
+			failures:   1,
+			maxRetries: 3,
+			fail: func(w http.ResponseWriter) {
+				w.Header().Set("X-RateLimit-Remaining", "0")
+				w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Unix(), 10))
+				w.WriteHeader(http.StatusForbidden)
+			},
+			wantStatus: http.StatusOK,
+			wantCalls:  2,
+		},
+		{
+			name:       "forbidden",
+			failures:   1,
+			maxRetries: 3,
+			fail:       func(w http.ResponseWriter) { w.WriteHeader(http.StatusForbidden) },
+			wantStatus: http.StatusForbidden,
+			wantCalls:  1,
+		},
+		{
+			name:       "not found",
+			failures:   1,
+			maxRetries: 3,
+			fail:       func(w http.ResponseWriter) { w.WriteHeader(http.StatusNotFound) },
+			wantStatus: http.StatusNotFound,
+			wantCalls:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			ts := httptest.NewServer(failingHandler(tt.failures, tt.fail, &calls))
+			defer ts.Close()
+
+			client := &http.Client{Transport: newTestRetryTransport(tt.maxRetries)}
+			resp, err := client.Post(ts.URL, "application/json", strings.NewReader(`{}`))
+
+			if err != nil {
+				t.Fatalf("RoundTrip returned error: %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("RoundTrip returned status %v, want %v", resp.StatusCode, tt.wantStatus)
+			}
+			if calls.Load() != tt.wantCalls {
+				t.Errorf("RoundTrip made %v requests, want %v", calls.Load(), tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRetryTransport_contextDeadline(t *testing.T) {
+	var calls atomic.Int32
+	ts := httptest.NewServer(failingHandler(1, func(w http.ResponseWriter) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}, &calls))
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	req, _ := http.NewRequestWithContext(ctx, "GET", ts.URL, nil)
+	client := &http.Client{Transport: newTestRetryTransport(3)}
+	resp, err := client.Do(req)
+
+	if err != nil {
+		t.Fatalf("RoundTrip returned error: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("RoundTrip returned status %v, want %v", resp.StatusCode, http.StatusTooManyRequests)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("RoundTrip made %v requests, want %v", calls.Load(), 1)
+	}
+}
+
+func TestRetryTransport_idempotentOnly(t *testing.T) {
+	tests := []struct {
+		name      string
+		method    string
+		fail      func(w http.ResponseWriter)
+		wantCalls int32
+	}{
+		{
+			name:      "server error of a GET",
+			method:    "GET",
+			fail:      func(w http.ResponseWriter) { w.WriteHeader(http.StatusBadGateway) },
+			wantCalls: 2,
+		},
+		{
+			name:      "server error of a POST",
+			method:    "POST",
+			fail:      func(w http.ResponseWriter) { w.WriteHeader(http.StatusBadGateway) },
+			wantCalls: 1,
+		},
+		{
+			name:      "unavailable POST without Retry-After",
+			method:    "POST",
+			fail:      func(w http.ResponseWriter) { w.WriteHeader(http.StatusServiceUnavailable) },
+			wantCalls: 1,
+		},
+		{
+			name:   "unavailable POST with Retry-After",
+			method: "POST",
+			fail: func(w http.ResponseWriter) {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusServiceUnavailable)
+			},
+			wantCalls: 2,
+		},
+		{
+			name:   "rate limited POST",
+			method: "POST",
+			fail: func(w http.ResponseWriter) {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusTooManyRequests)
+			},
+			wantCalls: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			ts := httptest.NewServer(failingHandler(1, tt.fail, &calls))
+			defer ts.Close()
+
+			transport := newTestRetryTransport(3)
+			transport.idempotentOnly = true
+			client := &http.Client{Transport: transport}
+			req, _ := http.NewRequest(tt.method, ts.URL, strings.NewReader(`{}`))
+			resp, err := client.Do(req)
+
+			if err != nil {
+				t.Fatalf("RoundTrip returned error: %v", err)
+			}
+			resp.Body.Close()
+
+			if calls.Load() != tt.wantCalls {
+				t.Errorf("RoundTrip made %v requests, want %v", calls.Load(), tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestNewClient_retries(t *testing.T) {
+	ctx := context.Background()
+
+	var calls atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/owner/repo/pulls/1", failingHandler(2, func(w http.ResponseWriter) {
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}, &calls))
+
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	apiUrl, _ := url.Parse(ts.URL)
+	client := NewClient(GithubClientOptions{owner: "owner", repo: "repo", githubToken: "githubToken", apiUrl: apiUrl, maxRetries: 2})
+
+	pr, _, err := client.client.PullRequests.Get(ctx, "owner", "repo", 1)
+
+	if err != nil {
+		t.Errorf("PullRequests.Get returned error: %v", err)
+	}
+
+	if pr.GetNumber() != 1 {
+		t.Errorf("PullRequests.Get returned %+v, want %+v", pr.GetNumber(), 1)
+	}
+}
+
+func TestNewClient_retriesOnlyIdempotent(t *testing.T) {
+	ctx := context.Background()
+
+	badGateway := func(w http.ResponseWriter) { w.WriteHeader(http.StatusBadGateway) }
+	tests := []struct {
+		name      string
+		fail      func(w http.ResponseWriter)
+		call      func(client *GithubClient) error
+		wantCalls int32
+	}{
+		{
+			name: "server error of a read",
+			fail: badGateway,
+			call: func(client *GithubClient) error {
+				_, _, err := client.client.PullRequests.Get(ctx, "owner", "repo", 1)
+				return err
+			},
+			wantCalls: 2,
+		},
+		{
+			name: "server error of a GraphQL query",
+			fail: badGateway,
+			call: func(client *GithubClient) error {
+				_, err := graphqlQuery[map[string]any](ctx, client, "query { viewer { login } }", nil)
+				return err
+			},
+			wantCalls: 2,
+		},
+		{
+			name: "server error of a write",
+			fail: badGateway,
+			call: func(client *GithubClient) error {
+				_, _, err := client.client.Git.CreateRef(ctx, "owner", "repo", &github.Reference{Ref: github.String("refs/tags/v1.0.0"), Object: &github.GitObject{SHA: github.String("sha")}})
+				return err
+			},
+			wantCalls: 1,
+		},
+		{
+			name: "rate limited write",
+			fail: func(w http.ResponseWriter) {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusForbidden)
+			},
+			call: func(client *GithubClient) error {
+				_, _, err := client.client.Git.CreateRef(ctx, "owner", "repo", &github.Reference{Ref: github.String("refs/tags/v1.0.0"), Object: &github.GitObject{SHA: github.String("sha")}})
+				return err
+			},
+			wantCalls: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			ts := httptest.NewServer(failingHandler(1, tt.fail, &calls))
+			defer ts.Close()
+
+			apiUrl, _ := url.Parse(ts.URL)
+			client := NewClient(GithubClientOptions{owner: "owner", repo: "repo", githubToken: "githubToken", apiUrl: apiUrl, maxRetries: 2})
+
+			err := tt.call(client)
+
+			if tt.wantCalls > 1 && err != nil {
+				t.Errorf("the request returned error: %v", err)
+			}
+			if calls.Load() != tt.wantCalls {
+				t.Errorf("the request was sent %v times, want %v", calls.Load(), tt.wantCalls)
+			}
+		})
+	}
+}
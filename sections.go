@@ -0,0 +1,68 @@
+package main
+
+import (
+	"strings"
+)
+
+const defaultOtherSectionTitle = "Other"
+
+// SectionConfig is one entry of --sections. A pull request belongs to the first section
+// that has one of its labels.
+type SectionConfig struct {
+	Title  string   `json:"title"`
+	Labels []string `json:"labels"`
+}
+
+// Section is a group of the release pull requests, passed to the template in order as sections.
+type Section struct {
+	Title        string                `json:"title"`
+	PullRequests []TemplatePullRequest `json:"pull_requests"`
+}
+
+// groupSections groups pullRequests into the configured sections, keeping their order.
+// Pull requests without a matching label go to a last section titled otherTitle.
+// Sections without pull requests are left out, so templates do not render empty headings.
+func groupSections(pullRequests []TemplatePullRequest, configs []SectionConfig, otherTitle string) []Section {
+	grouped := make([][]TemplatePullRequest, len(configs)+1)
+	for i := 0; i < len(pullRequests); i++ {
+		index := sectionIndex(pullRequests[i], configs)
+		grouped[index] = append(grouped[index], pullRequests[i])
+	}
+
+	sections := []Section{}
+	for i := 0; i < len(grouped); i++ {
+		if len(grouped[i]) == 0 {
+			continue
+		}
+
+		title := otherTitle
+		if i < len(configs) {
+			title = configs[i].Title
+		}
+		sections = append(sections, Section{Title: title, PullRequests: grouped[i]})
+	}
+
+	return sections
+}
+
+// sectionIndex returns the index of the first section of configs pr belongs to, or len(configs) for none.
+func sectionIndex(pr TemplatePullRequest, configs []SectionConfig) int {
+	for i := 0; i < len(configs); i++ {
+		if hasAnyLabel(pr, configs[i].Labels) {
+			return i
+		}
+	}
+	return len(configs)
+}
+
+// hasAnyLabel reports whether pr has one of labels. Labels are compared case-insensitively.
+func hasAnyLabel(pr TemplatePullRequest, labels []string) bool {
+	for i := 0; i < len(pr.Labels); i++ {
+		for j := 0; j < len(labels); j++ {
+			if strings.EqualFold(pr.Labels[i].GetName(), labels[j]) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v60/github"
+)
+
+func TestGroupSections(t *testing.T) {
+	pullRequest := func(number int, labels ...string) github.PullRequest {
+		pr := github.PullRequest{Number: github.Int(number)}
+		for _, label := range labels {
+			pr.Labels = append(pr.Labels, &github.Label{Name: github.String(label)})
+		}
+		return pr
+	}
+	pullRequests := newTemplatePullRequests([]github.PullRequest{
+		pullRequest(1, "feature"),
+		pullRequest(2, "Bug"),
+		pullRequest(3),
+		pullRequest(4, "bug", "feature"),
+		pullRequest(5, "dependencies"),
+	})
+	configs := []SectionConfig{
+		{Title: "Features", Labels: []string{"feature", "enhancement"}},
+		{Title: "Bug fixes", Labels: []string{"bug"}},
+		{Title: "Chores", Labels: []string{"chore"}},
+	}
+
+	tests := []struct {
+		name    string
+		configs []SectionConfig
+		want    [][]int
+	}{
+		{
+			name:    "grouped by the first matching section",
+			configs: configs,
+			want:    [][]int{{1, 4}, {2}, {3, 5}},
+		},
+		{
+			name:    "no sections",
+			configs: nil,
+			want:    [][]int{{1, 2, 3, 4, 5}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sections := groupSections(pullRequests, tt.configs, "Other")
+
+			got := [][]int{}
+			for _, section := range sections {
+				numbers := []int{}
+				for _, pr := range section.PullRequests {
+					numbers = append(numbers, pr.GetNumber())
+				}
+				got = append(got, numbers)
+			}
+			if !cmp.Equal(got, tt.want) {
+				t.Errorf("groupSections returned %v, want %v", got, tt.want)
+			}
+			if sections[len(sections)-1].Title != "Other" {
+				t.Errorf("groupSections returned %v as the last section, want %v", sections[len(sections)-1].Title, "Other")
+			}
+		})
+	}
+}
+
+func TestSectionsValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    []SectionConfig
+		wantErr string
+	}{
+		{
+			name: "sections",
+			raw:  `[{"title":"Features","labels":["feature"]},{"title":"Fixes","labels":["bug","fix"]}]`,
+			want: []SectionConfig{{Title: "Features", Labels: []string{"feature"}}, {Title: "Fixes", Labels: []string{"bug", "fix"}}},
+		},
+		{
+			name:    "missing title",
+			raw:     `[{"labels":["feature"]}]`,
+			wantErr: "section 1: title is required",
+		},
+		{
+			name:    "unknown field",
+			raw:     `[{"title":"Features","label":["feature"]}]`,
+			wantErr: `json: unknown field "label"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &sectionsValue{}
+			err := v.Set(tt.raw)
+
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("Set returned error %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Set returned error: %v", err)
+			}
+			if !cmp.Equal(v.values, tt.want) {
+				t.Errorf("Set returned %v, want %v", v.values, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+// PullRequestNode is a release pull request with the pull requests merged into its head branch,
+// passed to the template as pull_request_tree.
+type PullRequestNode struct {
+	TemplatePullRequest
+	// 0 for the pull requests merged into --from, 1 for their children and so on.
+	Depth    int               `json:"depth"`
+	Children []PullRequestNode `json:"children"`
+}
+
+// buildPullRequestTree nests the pull requests merged into the head branch of another one of
+// pullRequests under it, like the ones merged into a feature branch before it was merged.
+// The roots and the children keep the order of pullRequests.
+func buildPullRequestTree(pullRequests []TemplatePullRequest) []PullRequestNode {
+	parents := make([]int, len(pullRequests))
+	for i := 0; i < len(pullRequests); i++ {
+		parents[i] = parentIndex(pullRequests, i)
+	}
+	// Branches merged into each other both ways would make a cycle, which is broken at the first of them.
+	for i := 0; i < len(pullRequests); i++ {
+		for j, steps := parents[i], 0; j >= 0; j, steps = parents[j], steps+1 {
+			if j == i || steps > len(pullRequests) {
+				parents[i] = -1
+				break
+			}
+		}
+	}
+
+	return pullRequestNodes(pullRequests, parents, -1, 0)
+}
+
+// parentIndex returns the index of the pull request of pullRequests that child was merged into,
+// or -1 for none. When several pull requests had the base branch of child as their head branch,
+// like a branch reused for another pull request, the first one merged after child is its parent.
+func parentIndex(pullRequests []TemplatePullRequest, child int) int {
+	base := pullRequests[child].GetBase().GetRef()
+	if base == "" {
+		return -1
+	}
+
+	mergedAt := pullRequests[child].GetMergedAt().Time
+	parent := -1
+	for i := 0; i < len(pullRequests); i++ {
+		if i == child || pullRequests[i].GetHead().GetRef() != base {
+			continue
+		}
+		candidate := pullRequests[i].GetMergedAt().Time
+		if candidate.Before(mergedAt) {
+			continue
+		}
+		if parent == -1 || candidate.Before(pullRequests[parent].GetMergedAt().Time) {
+			parent = i
+		}
+	}
+	return parent
+}
+
+func pullRequestNodes(pullRequests []TemplatePullRequest, parents []int, parent int, depth int) []PullRequestNode {
+	nodes := []PullRequestNode{}
+	for i := 0; i < len(pullRequests); i++ {
+		if parents[i] != parent {
+			continue
+		}
+		nodes = append(nodes, PullRequestNode{
+			TemplatePullRequest: pullRequests[i],
+			Depth:               depth,
+			Children:            pullRequestNodes(pullRequests, parents, i, depth+1),
+		})
+	}
+	return nodes
+}
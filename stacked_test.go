@@ -0,0 +1,95 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v60/github"
+)
+
+func makeStackedPullRequest(number int, base string, head string, day int) github.PullRequest {
+	return github.PullRequest{
+		Number:   github.Int(number),
+		Base:     &github.PullRequestBranch{Ref: github.String(base)},
+		Head:     &github.PullRequestBranch{Ref: github.String(head)},
+		MergedAt: &github.Timestamp{Time: time.Date(2024, 1, day, 0, 0, 0, 0, time.UTC)},
+	}
+}
+
+// treeNumbers describes nodes as "number(children...)" for comparison.
+func treeNumbers(nodes []PullRequestNode) []any {
+	numbers := []any{}
+	for i := 0; i < len(nodes); i++ {
+		numbers = append(numbers, nodes[i].GetNumber())
+		if len(nodes[i].Children) > 0 {
+			numbers = append(numbers, treeNumbers(nodes[i].Children))
+		}
+	}
+	return numbers
+}
+
+func TestBuildPullRequestTree(t *testing.T) {
+	tests := []struct {
+		name         string
+		pullRequests []github.PullRequest
+		want         []any
+	}{
+		{
+			name: "flat",
+			pullRequests: []github.PullRequest{
+				makeStackedPullRequest(1, "main", "fix-a", 1),
+				makeStackedPullRequest(2, "main", "fix-b", 2),
+			},
+			want: []any{1, 2},
+		},
+		{
+			name: "feature branch",
+			pullRequests: []github.PullRequest{
+				makeStackedPullRequest(1, "feature/search", "search-api", 1),
+				makeStackedPullRequest(2, "main", "fix-a", 2),
+				makeStackedPullRequest(3, "search-api", "search-index", 1),
+				makeStackedPullRequest(4, "feature/search", "search-ui", 3),
+				makeStackedPullRequest(5, "main", "feature/search", 4),
+			},
+			want: []any{2, 5, []any{1, []any{3}, 4}},
+		},
+		{
+			name: "head branch reused",
+			pullRequests: []github.PullRequest{
+				makeStackedPullRequest(1, "main", "feature", 2),
+				makeStackedPullRequest(2, "feature", "part", 3),
+				makeStackedPullRequest(3, "main", "feature", 5),
+			},
+			want: []any{1, 3, []any{2}},
+		},
+		{
+			name: "cycle",
+			pullRequests: []github.PullRequest{
+				makeStackedPullRequest(1, "b", "a", 1),
+				makeStackedPullRequest(2, "a", "b", 1),
+			},
+			want: []any{1, []any{2}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := treeNumbers(buildPullRequestTree(newTemplatePullRequests(tt.pullRequests)))
+			if !cmp.Equal(got, tt.want) {
+				t.Errorf("buildPullRequestTree returned %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildPullRequestTree_depth(t *testing.T) {
+	tree := buildPullRequestTree(newTemplatePullRequests([]github.PullRequest{
+		makeStackedPullRequest(1, "feature", "part", 1),
+		makeStackedPullRequest(2, "main", "feature", 2),
+	}))
+
+	if tree[0].Depth != 0 || tree[0].Children[0].Depth != 1 {
+		t.Errorf("buildPullRequestTree returned depths %v and %v, want 0 and 1", tree[0].Depth, tree[0].Children[0].Depth)
+	}
+}
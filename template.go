@@ -7,7 +7,6 @@ import (
 	"os"
 
 	"github.com/cbroglie/mustache"
-	"github.com/google/go-github/v60/github"
 )
 
 //go:embed git-pr-release.mustache
@@ -27,11 +26,31 @@ func readTemplate(filename *string) (string, error) {
 }
 
 type RenderTemplateData struct {
-	PullRequests     []github.PullRequest `json:"pull_requests"`
-	Date             string               `json:"date"`
-	From             string               `json:"from"`
-	To               string               `json:"to"`
-	CustomParameters any                  `json:"custom_parameters"`
+	PullRequests     []TemplatePullRequest `json:"pull_requests"`
+	Date             string                `json:"date"`
+	From             string                `json:"from"`
+	To               string                `json:"to"`
+	CustomParameters any                   `json:"custom_parameters"`
+	// The hand-written part of the current release pull request body, with its markers.
+	// Templates place it with {{{manual_section}}}.
+	ManualSection string `json:"manual_section"`
+	// The pull requests grouped by --sections, with the ones matching no section last.
+	Sections []Section `json:"sections"`
+	// The pull requests classified by Conventional Commits titles.
+	Features        []TemplatePullRequest `json:"features"`
+	Fixes           []TemplatePullRequest `json:"fixes"`
+	BreakingChanges []TemplatePullRequest `json:"breaking_changes"`
+	// The pull requests with the ones merged into their head branches nested as children.
+	PullRequestTree []PullRequestNode `json:"pull_request_tree"`
+	// Set by --linked-issues. The issues the pull requests close, each once.
+	Issues []ReleaseIssue `json:"issues"`
+	// Set by --tracker-key-pattern. The tracker keys of the pull requests, each once.
+	TrackerKeys []ReleaseTrackerKey `json:"tracker_keys"`
+	// The pull requests left out by the exclude options, with the reason.
+	Excluded []ExcludedPullRequest `json:"excluded_pull_requests"`
+	// Set by --suggest-version. PreviousVersion is empty when no version tag is reachable from To.
+	PreviousVersion string `json:"previous_version"`
+	NextVersion     string `json:"next_version"`
 }
 
 func convertJson(data RenderTemplateData) (any, error) {
@@ -31,14 +31,14 @@ func TestRenderTemplate(t *testing.T) {
 	os.Setenv("GITHUB_RUN_ATTEMPT", "")
 
 	data := RenderTemplateData{
-		PullRequests: []github.PullRequest{
+		PullRequests: newTemplatePullRequests([]github.PullRequest{
 			{
 				Number: github.Int(1),
 			},
 			{
 				Number: github.Int(2),
 			},
-		},
+		}),
 		Date: "2021-01-01",
 	}
 
@@ -141,16 +141,57 @@ func TestRenderTemplate(t *testing.T) {
 	})
 }
 
+func TestRenderTemplateWithManualSection(t *testing.T) {
+	data := RenderTemplateData{
+		ManualSection: manualSectionStart + "\nRun the migration first.\n" + manualSectionEnd,
+	}
+
+	filename := makeDummyTemplate("Release\n{{{manual_section}}}")
+	defer os.Remove(filename)
+	template, err := RenderTemplate(&filename, data, true)
+
+	if err != nil {
+		t.Errorf("RenderTemplate returned error: %v", err)
+	}
+
+	want := "Release\n" + data.ManualSection
+	if template != want {
+		t.Errorf("RenderTemplate returned %v, want %v", template, want)
+	}
+}
+
+func TestRenderTemplateWithSections(t *testing.T) {
+	data := RenderTemplateData{
+		Sections: []Section{
+			{Title: "Features", PullRequests: newTemplatePullRequests([]github.PullRequest{{Number: github.Int(1)}, {Number: github.Int(3)}})},
+			{Title: "Other", PullRequests: newTemplatePullRequests([]github.PullRequest{{Number: github.Int(2)}})},
+		},
+	}
+
+	filename := makeDummyTemplate("Release\n{{#sections}}\n## {{title}}\n{{#pull_requests}}\n- #{{number}}\n{{/pull_requests}}\n{{/sections}}\n")
+	defer os.Remove(filename)
+	template, err := RenderTemplate(&filename, data, true)
+
+	if err != nil {
+		t.Errorf("RenderTemplate returned error: %v", err)
+	}
+
+	want := "Release\n## Features\n- #1\n- #3\n## Other\n- #2\n"
+	if template != want {
+		t.Errorf("RenderTemplate returned %q, want %q", template, want)
+	}
+}
+
 func TestRenderTemplateWithFilename(t *testing.T) {
 	data := RenderTemplateData{
-		PullRequests: []github.PullRequest{
+		PullRequests: newTemplatePullRequests([]github.PullRequest{
 			{
 				Number: github.Int(1),
 			},
 			{
 				Number: github.Int(2),
 			},
-		},
+		}),
 		Date: "2021-01-01",
 	}
 
@@ -194,3 +235,66 @@ func TestGetRunUrl(t *testing.T) {
 		}
 	})
 }
+
+func TestRenderTemplateWithExcludedPullRequests(t *testing.T) {
+	data := RenderTemplateData{
+		Excluded: []ExcludedPullRequest{
+			{TemplatePullRequest: newTemplatePullRequests([]github.PullRequest{{Number: github.Int(2)}})[0], Reason: "label skip-release"},
+		},
+	}
+
+	filename := makeDummyTemplate("Release\n{{#excluded_pull_requests}}\n- #{{number}} ({{reason}})\n{{/excluded_pull_requests}}\n")
+	defer os.Remove(filename)
+	template, err := RenderTemplate(&filename, data, true)
+
+	if err != nil {
+		t.Errorf("RenderTemplate returned error: %v", err)
+	}
+
+	want := "Release\n- #2 (label skip-release)\n"
+	if template != want {
+		t.Errorf("RenderTemplate returned %q, want %q", template, want)
+	}
+}
+
+func TestRenderTemplateWithPullRequestTree(t *testing.T) {
+	data := RenderTemplateData{
+		PullRequestTree: buildPullRequestTree(newTemplatePullRequests([]github.PullRequest{
+			{Number: github.Int(1), Base: &github.PullRequestBranch{Ref: github.String("feature")}},
+			{Number: github.Int(2), Base: &github.PullRequestBranch{Ref: github.String("main")}, Head: &github.PullRequestBranch{Ref: github.String("feature")}},
+		})),
+	}
+
+	filename := makeDummyTemplate("Release\n{{#pull_request_tree}}\n- #{{number}}\n{{#children}}\n  - #{{number}}\n{{/children}}\n{{/pull_request_tree}}\n")
+	defer os.Remove(filename)
+	template, err := RenderTemplate(&filename, data, true)
+
+	if err != nil {
+		t.Errorf("RenderTemplate returned error: %v", err)
+	}
+
+	want := "Release\n- #2\n  - #1\n"
+	if template != want {
+		t.Errorf("RenderTemplate returned %q, want %q", template, want)
+	}
+}
+
+func TestRenderTemplateWithTrackerKeys(t *testing.T) {
+	pullRequests := newTemplatePullRequests([]github.PullRequest{{Number: github.Int(1)}, {Number: github.Int(2)}})
+	pullRequests[0].TrackerKeys = []TrackerKey{{Key: "PAY-1", Url: "https://jira.example.com/browse/PAY-1"}}
+	pullRequests[1].TrackerKeys = []TrackerKey{}
+	data := RenderTemplateData{PullRequests: pullRequests, TrackerKeys: collectTrackerKeys(pullRequests)}
+
+	filename := makeDummyTemplate("Release\n{{#pull_requests}}\n- #{{number}}{{#tracker_keys}} [{{key}}]({{{url}}}){{/tracker_keys}}\n{{/pull_requests}}\n")
+	defer os.Remove(filename)
+	template, err := RenderTemplate(&filename, data, true)
+
+	if err != nil {
+		t.Errorf("RenderTemplate returned error: %v", err)
+	}
+
+	want := "Release\n- #1 [PAY-1](https://jira.example.com/browse/PAY-1)\n- #2\n"
+	if template != want {
+		t.Errorf("RenderTemplate returned %q, want %q", template, want)
+	}
+}
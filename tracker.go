@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v60/github"
+)
+
+const (
+	trackerJira    = "jira"
+	trackerWebhook = "webhook"
+)
+
+// Tracker updates the issues of an external issue tracker about the release pull request.
+// Both methods are called again on every run, so they should do nothing when already done.
+type Tracker interface {
+	// Comment adds comment to the issue key.
+	Comment(ctx context.Context, key string, comment string) error
+	// Transition moves the issue key to the status named status.
+	Transition(ctx context.Context, key string, status string) error
+}
+
+// TrackerFailure is a tracker key whose issue could not be updated.
+type TrackerFailure struct {
+	Key   string `json:"key"`
+	Error string `json:"error"`
+}
+
+func newTracker(options Options) Tracker {
+	transport := newRetryTransport(http.DefaultTransport, options.maxRetries)
+	// Comments and webhook events are POSTs that would be repeated by a retry after a server error.
+	transport.idempotentOnly = true
+	httpClient := &http.Client{Transport: transport}
+	switch options.tracker {
+	case trackerJira:
+		return &jiraTracker{client: httpClient, baseUrl: options.trackerApiUrl, user: options.trackerUser, token: options.trackerToken}
+	case trackerWebhook:
+		return &webhookTracker{client: httpClient, url: options.trackerApiUrl, token: options.trackerToken}
+	}
+	return nil
+}
+
+// updateTracker comments on and transitions the issues of keys as the options ask.
+// A key that fails does not stop the others; the failures are returned instead.
+func updateTracker(ctx context.Context, tracker Tracker, options Options, keys []ReleaseTrackerKey, pr *github.PullRequest) []TrackerFailure {
+	comment := strings.NewReplacer(
+		"{number}", strconv.Itoa(pr.GetNumber()),
+		"{url}", pr.GetHTMLURL(),
+		"{title}", pr.GetTitle(),
+	).Replace(options.trackerComment)
+
+	failures := []TrackerFailure{}
+	for i := 0; i < len(keys); i++ {
+		key := keys[i].Key
+		var err error
+		if options.trackerComment != "" {
+			err = tracker.Comment(ctx, key, comment)
+		}
+		if err == nil && options.trackerTransition != "" {
+			err = tracker.Transition(ctx, key, options.trackerTransition)
+		}
+		if err != nil {
+			logger.Printf("Failed to update the issue %s: %v\n", key, err)
+			failures = append(failures, TrackerFailure{Key: key, Error: err.Error()})
+		}
+	}
+	if len(failures) < len(keys) {
+		logger.Printf("Updated %d issues in the tracker.\n", len(keys)-len(failures))
+	}
+	return failures
+}
+
+// jiraTracker updates the issues of Jira with its REST API.
+// With a user, it authenticates with the user and an API token as on Jira Cloud,
+// otherwise with the token as a personal access token as on Jira Data Center.
+type jiraTracker struct {
+	client  *http.Client
+	baseUrl *url.URL
+	user    string
+	token   string
+}
+
+// Comment skips the issues that already have comment among their latest comments.
+func (t *jiraTracker) Comment(ctx context.Context, key string, comment string) error {
+	var comments struct {
+		Comments []struct {
+			Body string `json:"body"`
+		} `json:"comments"`
+	}
+	err := t.do(ctx, "GET", "issue/"+url.PathEscape(key)+"/comment?orderBy=-created&maxResults=100", nil, &comments)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < len(comments.Comments); i++ {
+		if comments.Comments[i].Body == comment {
+			return nil
+		}
+	}
+
+	return t.do(ctx, "POST", "issue/"+url.PathEscape(key)+"/comment", map[string]any{"body": comment}, nil)
+}
+
+// Transition skips the issues already in status. status is compared case-insensitively with
+// the names of the statuses the transitions lead to, and with the names of the transitions.
+func (t *jiraTracker) Transition(ctx context.Context, key string, status string) error {
+	var issue struct {
+		Fields struct {
+			Status struct {
+				Name string `json:"name"`
+			} `json:"status"`
+		} `json:"fields"`
+	}
+	err := t.do(ctx, "GET", "issue/"+url.PathEscape(key)+"?fields=status", nil, &issue)
+	if err != nil {
+		return err
+	}
+	if strings.EqualFold(issue.Fields.Status.Name, status) {
+		return nil
+	}
+
+	var transitions struct {
+		Transitions []struct {
+			Id   string `json:"id"`
+			Name string `json:"name"`
+			To   struct {
+				Name string `json:"name"`
+			} `json:"to"`
+		} `json:"transitions"`
+	}
+	err = t.do(ctx, "GET", "issue/"+url.PathEscape(key)+"/transitions", nil, &transitions)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < len(transitions.Transitions); i++ {
+		transition := transitions.Transitions[i]
+		if strings.EqualFold(transition.To.Name, status) || strings.EqualFold(transition.Name, status) {
+			return t.do(ctx, "POST", "issue/"+url.PathEscape(key)+"/transitions", map[string]any{"transition": map[string]string{"id": transition.Id}}, nil)
+		}
+	}
+
+	return fmt.Errorf("no transition from %q to %q", issue.Fields.Status.Name, status)
+}
+
+// do calls the Jira REST API at path and decodes the response into result, unless it is nil.
+func (t *jiraTracker) do(ctx context.Context, method string, path string, body any, result any) error {
+	header := http.Header{}
+	if t.user != "" {
+		header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(t.user+":"+t.token)))
+	} else if t.token != "" {
+		header.Set("Authorization", "Bearer "+t.token)
+	}
+	endpoint := strings.TrimSuffix(t.baseUrl.String(), "/") + "/rest/api/2/" + path
+	return doTrackerRequest(ctx, t.client, method, endpoint, header, body, result)
+}
+
+// webhookTracker posts every update as JSON to a URL, for trackers without a built-in implementation.
+// The body is {"action": "comment", "key": ..., "comment": ...} or {"action": "transition", "key": ..., "status": ...}.
+type webhookTracker struct {
+	client *http.Client
+	url    *url.URL
+	token  string
+}
+
+func (t *webhookTracker) Comment(ctx context.Context, key string, comment string) error {
+	return t.post(ctx, map[string]string{"action": "comment", "key": key, "comment": comment})
+}
+
+func (t *webhookTracker) Transition(ctx context.Context, key string, status string) error {
+	return t.post(ctx, map[string]string{"action": "transition", "key": key, "status": status})
+}
+
+func (t *webhookTracker) post(ctx context.Context, body map[string]string) error {
+	header := http.Header{}
+	if t.token != "" {
+		header.Set("Authorization", "Bearer "+t.token)
+	}
+	return doTrackerRequest(ctx, t.client, "POST", t.url.String(), header, body, nil)
+}
+
+// doTrackerRequest sends body as JSON and decodes the response into result, unless it is nil.
+// Responses other than 2xx are errors with the start of their body.
+func doTrackerRequest(ctx context.Context, client *http.Client, method string, endpoint string, header http.Header, body any, result any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s %s returned %d: %s", method, req.URL.Path, resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// TrackerKey is a key of an external issue tracker found in a pull request, like PAY-1234 of Jira.
+type TrackerKey struct {
+	Key string `json:"key"`
+	// The link made from --tracker-url, or "" without it.
+	Url string `json:"url"`
+}
+
+// ReleaseTrackerKey is a tracker key found in one or more of the release pull requests, passed to the template as tracker_keys.
+type ReleaseTrackerKey struct {
+	TrackerKey
+	PullRequestNumbers []int `json:"pull_request_numbers"`
+}
+
+// trackerKeyFinder finds the tracker keys of pull requests with --tracker-key-pattern and links them with --tracker-url.
+type trackerKeyFinder struct {
+	pattern *regexp.Regexp
+	// Holds {key} where the key goes.
+	url string
+}
+
+// find returns the keys in the title and the head branch name of pr, in order and without duplicates.
+// When the pattern has a group, the first group is the key, so the pattern can match around it.
+func (f trackerKeyFinder) find(pr TemplatePullRequest) []TrackerKey {
+	keys := []TrackerKey{}
+	texts := []string{pr.GetTitle(), pr.GetHead().GetRef()}
+	for i := 0; i < len(texts); i++ {
+		for _, match := range f.pattern.FindAllStringSubmatch(texts[i], -1) {
+			key := match[0]
+			if len(match) > 1 {
+				key = match[1]
+			}
+			if key == "" || containsTrackerKey(keys, key) {
+				continue
+			}
+			keys = append(keys, TrackerKey{Key: key, Url: f.link(key)})
+		}
+	}
+	return keys
+}
+
+func (f trackerKeyFinder) link(key string) string {
+	if f.url == "" {
+		return ""
+	}
+	return strings.ReplaceAll(f.url, "{key}", url.PathEscape(key))
+}
+
+// containsTrackerKey reports whether keys has key. Branch names are often lower-cased, so keys are compared case-insensitively.
+func containsTrackerKey(keys []TrackerKey, key string) bool {
+	for i := 0; i < len(keys); i++ {
+		if strings.EqualFold(keys[i].Key, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// collectTrackerKeys returns the tracker keys of pullRequests without duplicates, in the order they first appear,
+// each with the numbers of the pull requests that have it.
+func collectTrackerKeys(pullRequests []TemplatePullRequest) []ReleaseTrackerKey {
+	keys := []ReleaseTrackerKey{}
+	indexes := map[string]int{}
+	for i := 0; i < len(pullRequests); i++ {
+		for j := 0; j < len(pullRequests[i].TrackerKeys); j++ {
+			key := pullRequests[i].TrackerKeys[j]
+			index, ok := indexes[strings.ToUpper(key.Key)]
+			if !ok {
+				index = len(keys)
+				indexes[strings.ToUpper(key.Key)] = index
+				keys = append(keys, ReleaseTrackerKey{TrackerKey: key, PullRequestNumbers: []int{}})
+			}
+			keys[index].PullRequestNumbers = append(keys[index].PullRequestNumbers, pullRequests[i].GetNumber())
+		}
+	}
+	return keys
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v60/github"
+)
+
+func TestTrackerKeyFinder(t *testing.T) {
+	tests := []struct {
+		name   string
+		finder trackerKeyFinder
+		pr     github.PullRequest
+		want   []TrackerKey
+	}{
+		{
+			name:   "title and branch",
+			finder: trackerKeyFinder{pattern: regexp.MustCompile(`[A-Z][A-Z0-9]+-[0-9]+`), url: "https://jira.example.com/browse/{key}"},
+			pr:     github.PullRequest{Title: github.String("PAY-1234 PAY-1235: Refund twice"), Head: &github.PullRequestBranch{Ref: github.String("OPS-7-refunds")}},
+			want: []TrackerKey{
+				{Key: "PAY-1234", Url: "https://jira.example.com/browse/PAY-1234"},
+				{Key: "PAY-1235", Url: "https://jira.example.com/browse/PAY-1235"},
+				{Key: "OPS-7", Url: "https://jira.example.com/browse/OPS-7"},
+			},
+		},
+		{
+			name:   "duplicates",
+			finder: trackerKeyFinder{pattern: regexp.MustCompile(`(?i)pay-[0-9]+`)},
+			pr:     github.PullRequest{Title: github.String("PAY-1234: Refund twice"), Head: &github.PullRequestBranch{Ref: github.String("pay-1234-refunds")}},
+			want:   []TrackerKey{{Key: "PAY-1234", Url: ""}},
+		},
+		{
+			name:   "group",
+			finder: trackerKeyFinder{pattern: regexp.MustCompile(`\[([A-Z]+-[0-9]+)\]`), url: "https://tracker.example.com/{key}"},
+			pr:     github.PullRequest{Title: github.String("[PAY-1234] Refund twice, not UTF-8")},
+			want:   []TrackerKey{{Key: "PAY-1234", Url: "https://tracker.example.com/PAY-1234"}},
+		},
+		{
+			name:   "none",
+			finder: trackerKeyFinder{pattern: regexp.MustCompile(`[A-Z][A-Z0-9]+-[0-9]+`)},
+			pr:     github.PullRequest{Title: github.String("Refund twice")},
+			want:   []TrackerKey{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.finder.find(newTemplatePullRequests([]github.PullRequest{tt.pr})[0])
+			if !cmp.Equal(got, tt.want) {
+				t.Errorf("find returned %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCollectTrackerKeys(t *testing.T) {
+	pullRequests := newTemplatePullRequests([]github.PullRequest{{Number: github.Int(1)}, {Number: github.Int(2)}, {Number: github.Int(3)}})
+	pullRequests[0].TrackerKeys = []TrackerKey{{Key: "PAY-1"}}
+	pullRequests[2].TrackerKeys = []TrackerKey{{Key: "OPS-2"}, {Key: "pay-1"}}
+
+	got := collectTrackerKeys(pullRequests)
+
+	want := []ReleaseTrackerKey{
+		{TrackerKey: TrackerKey{Key: "PAY-1"}, PullRequestNumbers: []int{1, 3}},
+		{TrackerKey: TrackerKey{Key: "OPS-2"}, PullRequestNumbers: []int{3}},
+	}
+	if !cmp.Equal(got, want) {
+		t.Errorf("collectTrackerKeys returned %+v, want %+v", got, want)
+	}
+}
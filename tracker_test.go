@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v60/github"
+)
+
+func newTestJiraTracker(ts *httptest.Server) *jiraTracker {
+	baseUrl, _ := url.Parse(ts.URL + "/jira/")
+	return &jiraTracker{client: ts.Client(), baseUrl: baseUrl, user: "release@example.com", token: "token"}
+}
+
+func TestJiraTracker_Comment(t *testing.T) {
+	ctx := context.Background()
+
+	mux := http.NewServeMux()
+
+	var posted []string
+	handler := func(existing string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			user, password, ok := r.BasicAuth()
+			if !ok || user != "release@example.com" || password != "token" {
+				t.Errorf("jira request was not authenticated: %v", r.Header.Get("Authorization"))
+			}
+			if r.Method == "GET" {
+				fmt.Fprintf(w, `{"comments": [{"body": %q}]}`, existing)
+				return
+			}
+			var body struct {
+				Body string `json:"body"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			posted = append(posted, r.URL.Path+" "+body.Body)
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{}`)
+		}
+	}
+	mux.HandleFunc("/jira/rest/api/2/issue/PAY-1/comment", handler("Looks good"))
+	mux.HandleFunc("/jira/rest/api/2/issue/PAY-2/comment", handler("Included in #10"))
+	mux.HandleFunc("/jira/rest/api/2/issue/PAY-3/comment", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"errorMessages": ["Issue does not exist"]}`)
+	})
+
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	tracker := newTestJiraTracker(ts)
+
+	if err := tracker.Comment(ctx, "PAY-1", "Included in #10"); err != nil {
+		t.Errorf("Comment returned error: %v", err)
+	}
+	if err := tracker.Comment(ctx, "PAY-2", "Included in #10"); err != nil {
+		t.Errorf("Comment returned error: %v", err)
+	}
+	want := []string{"/jira/rest/api/2/issue/PAY-1/comment Included in #10"}
+	if !cmp.Equal(posted, want) {
+		t.Errorf("Comment posted %v, want %v", posted, want)
+	}
+
+	err := tracker.Comment(ctx, "PAY-3", "Included in #10")
+	wantErr := `GET /jira/rest/api/2/issue/PAY-3/comment returned 404: {"errorMessages": ["Issue does not exist"]}`
+	if err == nil || err.Error() != wantErr {
+		t.Errorf("Comment returned error %v, want %v", err, wantErr)
+	}
+}
+
+func TestJiraTracker_Transition(t *testing.T) {
+	ctx := context.Background()
+
+	mux := http.NewServeMux()
+
+	statuses := map[string]string{"PAY-1": "In Review", "PAY-2": "Ready for Release", "PAY-3": "Closed"}
+	var transitioned []string
+	mux.HandleFunc("/jira/rest/api/2/issue/", func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Query().Get("fields") == "status":
+			key := r.URL.Path[len("/jira/rest/api/2/issue/"):]
+			fmt.Fprintf(w, `{"fields": {"status": {"name": %q}}}`, statuses[key])
+		case r.Method == "GET":
+			fmt.Fprint(w, `{"transitions": [{"id": "21", "name": "Ship", "to": {"name": "Ready for release"}}, {"id": "31", "name": "Reopen", "to": {"name": "Open"}}]}`)
+		default:
+			var body struct {
+				Transition struct {
+					Id string `json:"id"`
+				} `json:"transition"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			transitioned = append(transitioned, r.URL.Path+" "+body.Transition.Id)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	tracker := newTestJiraTracker(ts)
+
+	if err := tracker.Transition(ctx, "PAY-1", "ready for release"); err != nil {
+		t.Errorf("Transition returned error: %v", err)
+	}
+	if err := tracker.Transition(ctx, "PAY-2", "ready for release"); err != nil {
+		t.Errorf("Transition returned error: %v", err)
+	}
+	want := []string{"/jira/rest/api/2/issue/PAY-1/transitions 21"}
+	if !cmp.Equal(transitioned, want) {
+		t.Errorf("Transition posted %v, want %v", transitioned, want)
+	}
+
+	err := tracker.Transition(ctx, "PAY-3", "Done")
+	wantErr := `no transition from "Closed" to "Done"`
+	if err == nil || err.Error() != wantErr {
+		t.Errorf("Transition returned error %v, want %v", err, wantErr)
+	}
+}
+
+func TestWebhookTracker(t *testing.T) {
+	ctx := context.Background()
+
+	var received []map[string]string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("webhook request has Authorization %q, want %q", r.Header.Get("Authorization"), "Bearer secret")
+		}
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		received = append(received, body)
+	}))
+	defer ts.Close()
+
+	webhookUrl, _ := url.Parse(ts.URL + "/hooks/release")
+	tracker := &webhookTracker{client: ts.Client(), url: webhookUrl, token: "secret"}
+
+	if err := tracker.Comment(ctx, "PAY-1", "Included in #10"); err != nil {
+		t.Errorf("Comment returned error: %v", err)
+	}
+	if err := tracker.Transition(ctx, "PAY-1", "Ready for release"); err != nil {
+		t.Errorf("Transition returned error: %v", err)
+	}
+
+	want := []map[string]string{
+		{"action": "comment", "key": "PAY-1", "comment": "Included in #10"},
+		{"action": "transition", "key": "PAY-1", "status": "Ready for release"},
+	}
+	if !cmp.Equal(received, want) {
+		t.Errorf("webhook received %v, want %v", received, want)
+	}
+}
+
+// fakeTracker records the calls and fails for the keys in fail.
+type fakeTracker struct {
+	calls []string
+	fail  map[string]bool
+}
+
+func (t *fakeTracker) Comment(ctx context.Context, key string, comment string) error {
+	t.calls = append(t.calls, "comment "+key+": "+comment)
+	if t.fail[key] {
+		return errors.New("unavailable")
+	}
+	return nil
+}
+
+func (t *fakeTracker) Transition(ctx context.Context, key string, status string) error {
+	t.calls = append(t.calls, "transition "+key+": "+status)
+	return nil
+}
+
+func TestUpdateTracker(t *testing.T) {
+	logger = GetLogger()
+	tracker := &fakeTracker{fail: map[string]bool{"PAY-2": true}}
+	options := Options{trackerComment: "Included in #{number} {url}", trackerTransition: "Ready for release"}
+	keys := []ReleaseTrackerKey{{TrackerKey: TrackerKey{Key: "PAY-1"}}, {TrackerKey: TrackerKey{Key: "PAY-2"}}, {TrackerKey: TrackerKey{Key: "OPS-3"}}}
+	pr := &github.PullRequest{Number: github.Int(10), HTMLURL: github.String("https://github.com/owner/repo/pull/10")}
+
+	failures := updateTracker(context.Background(), tracker, options, keys, pr)
+
+	wantCalls := []string{
+		"comment PAY-1: Included in #10 https://github.com/owner/repo/pull/10",
+		"transition PAY-1: Ready for release",
+		"comment PAY-2: Included in #10 https://github.com/owner/repo/pull/10",
+		"comment OPS-3: Included in #10 https://github.com/owner/repo/pull/10",
+		"transition OPS-3: Ready for release",
+	}
+	if !cmp.Equal(tracker.calls, wantCalls) {
+		t.Errorf("updateTracker called %v, want %v", tracker.calls, wantCalls)
+	}
+
+	wantFailures := []TrackerFailure{{Key: "PAY-2", Error: "unavailable"}}
+	if !cmp.Equal(failures, wantFailures) {
+		t.Errorf("updateTracker returned %v, want %v", failures, wantFailures)
+	}
+}
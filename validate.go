@@ -0,0 +1,205 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// optionProblem is one thing wrong with the options, with a hint on how to fix it.
+type optionProblem struct {
+	message string
+	hint    string
+}
+
+// OptionsError reports every problem found in the options at once, so they can all be fixed in one go.
+type OptionsError struct {
+	// The pipeline the options were read for. Empty without pipelines.
+	Pipeline string
+	Problems []optionProblem
+}
+
+func (e *OptionsError) Error() string {
+	var b strings.Builder
+	if e.Pipeline == "" {
+		b.WriteString("invalid options:")
+	} else {
+		fmt.Fprintf(&b, "invalid options for the pipeline %s:", e.Pipeline)
+	}
+	for i := 0; i < len(e.Problems); i++ {
+		fmt.Fprintf(&b, "\n  - %s", e.Problems[i].message)
+		if e.Problems[i].hint != "" {
+			fmt.Fprintf(&b, "\n    %s", e.Problems[i].hint)
+		}
+	}
+	return b.String()
+}
+
+// optionValidator collects the problems of the options of one pipeline.
+type optionValidator struct {
+	problems []optionProblem
+}
+
+func (v *optionValidator) add(message, hint string) {
+	v.problems = append(v.problems, optionProblem{message: message, hint: hint})
+}
+
+func (v *optionValidator) err(pipeline string) error {
+	if len(v.problems) == 0 {
+		return nil
+	}
+	return &OptionsError{Pipeline: pipeline, Problems: v.problems}
+}
+
+// required reports the flag named name when value is empty.
+func (v *optionValidator) required(name, value string) {
+	if value != "" {
+		return
+	}
+	configKey := strings.ReplaceAll(name, "-", "_")
+	v.add(
+		fmt.Sprintf("--%s is required.", name),
+		fmt.Sprintf("Pass --%s, set %s, set %s in the config file or select a pipeline with --pipeline.", name, envName(name), configKey),
+	)
+}
+
+// repository returns the owner and the name of the repository from --repo, or else GITHUB_REPOSITORY,
+// or else the origin remote of gitDir. The remote is returned when the repository was read from it.
+func (v *optionValidator) repository(flagValue, envValue, gitDir string) (string, string, *gitRemote) {
+	value, source := flagValue, "--repo"
+	if value == "" {
+		value, source = envValue, "GITHUB_REPOSITORY"
+	}
+
+	if value == "" {
+		if gitDir == "" {
+			gitDir = defaultGitDir
+		}
+		remote, err := readGitRemote(gitDir, "origin")
+		if err != nil {
+			v.add("The repository is not set.", fmt.Sprintf("Pass --repo owner/name or set GITHUB_REPOSITORY. GitHub Actions sets GITHUB_REPOSITORY. It could not be read from the origin remote either: %v.", err))
+			return "", "", nil
+		}
+		return remote.owner, remote.repo, remote
+	}
+
+	owner, repo, ok := strings.Cut(value, "/")
+	if !ok || owner == "" || repo == "" || strings.Contains(repo, "/") {
+		v.add(fmt.Sprintf("%s must be owner/name, got %q.", source, value), "For example, odanado/git-pr-release-go.")
+		return "", "", nil
+	}
+	return owner, repo, nil
+}
+
+// token returns the GitHub token from the file given by --token-file, or else GITHUB_TOKEN.
+func (v *optionValidator) token(tokenFile, envValue string) string {
+	if tokenFile == "" {
+		if envValue == "" {
+			v.add("The GitHub token is not set.", "Set GITHUB_TOKEN or pass --token-file with the path to a file that holds the token.")
+		}
+		return envValue
+	}
+
+	data, err := os.ReadFile(tokenFile)
+	if err != nil {
+		v.add(fmt.Sprintf("--token-file cannot be read: %v.", err), "")
+		return ""
+	}
+
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		v.add(fmt.Sprintf("--token-file %s is empty.", tokenFile), "")
+	}
+	return token
+}
+
+// apiUrl returns GITHUB_API_URL parsed. When it is not set, it returns the API URL of the host of remote,
+// or nil for the public GitHub API.
+func (v *optionValidator) apiUrl(envValue string, remote *gitRemote) *url.URL {
+	if envValue == "" {
+		if remote != nil {
+			return remote.apiUrl()
+		}
+		return nil
+	}
+
+	apiUrl, err := url.Parse(envValue)
+	if err == nil && (apiUrl.Scheme == "" || apiUrl.Host == "") {
+		err = fmt.Errorf("missing scheme or host")
+	}
+	if err != nil {
+		v.add(fmt.Sprintf("GITHUB_API_URL %q is not a valid URL: %v.", envValue, err), "For example, https://api.github.com or https://github.example.com/api/v3.")
+		return nil
+	}
+	return apiUrl
+}
+
+// regexp returns value compiled, or nil when it is empty.
+func (v *optionValidator) regexp(name, value string) *regexp.Regexp {
+	if value == "" {
+		return nil
+	}
+
+	pattern, err := regexp.Compile(value)
+	if err != nil {
+		v.add(fmt.Sprintf("--%s is not a valid regular expression: %v.", name, err), "The syntax is described at https://pkg.go.dev/regexp/syntax.")
+		return nil
+	}
+	return pattern
+}
+
+// trackerUrl reports a --tracker-url that cannot link the keys of pattern.
+func (v *optionValidator) trackerUrl(value, pattern string) {
+	if value == "" {
+		return
+	}
+	if pattern == "" {
+		v.add("--tracker-url is set without --tracker-key-pattern.", "Pass --tracker-key-pattern with a regular expression matching the keys, like [A-Z][A-Z0-9]+-[0-9]+.")
+	}
+	if !strings.Contains(value, "{key}") {
+		v.add(fmt.Sprintf("--tracker-url must contain {key}, got %q.", value), "For example, https://jira.example.com/browse/{key}.")
+	}
+}
+
+// tracker returns the parsed --tracker-api-url of tracker, and reports the options --tracker needs.
+func (v *optionValidator) tracker(tracker, apiUrl, keyPattern, comment, transition, token string) *url.URL {
+	if tracker == "" {
+		if comment != "" || transition != "" {
+			v.add("--tracker-comment and --tracker-transition need --tracker.", "Pass --tracker jira or --tracker webhook.")
+		}
+		return nil
+	}
+
+	if keyPattern == "" {
+		v.add("--tracker is set without --tracker-key-pattern.", "Pass --tracker-key-pattern with a regular expression matching the keys, like [A-Z][A-Z0-9]+-[0-9]+.")
+	}
+	if comment == "" && transition == "" {
+		v.add("--tracker is set without --tracker-comment or --tracker-transition.", "Pass what to do with the issues, like --tracker-comment 'Included in #{number}'.")
+	}
+	if tracker == trackerJira && token == "" {
+		v.add("The Jira token is not set.", "Set TRACKER_TOKEN to an API token, with TRACKER_USER set to its email on Jira Cloud, or to a personal access token on Jira Data Center.")
+	}
+
+	if apiUrl == "" {
+		v.add("--tracker-api-url is required with --tracker.", "For example, https://example.atlassian.net for Jira.")
+		return nil
+	}
+	parsed, err := url.Parse(apiUrl)
+	if err == nil && (parsed.Scheme == "" || parsed.Host == "") {
+		err = fmt.Errorf("missing scheme or host")
+	}
+	if err != nil {
+		v.add(fmt.Sprintf("--tracker-api-url %q is not a valid URL: %v.", apiUrl, err), "For example, https://example.atlassian.net for Jira.")
+		return nil
+	}
+	return parsed
+}
+
+// atLeast reports the flag named name when value is below min.
+func (v *optionValidator) atLeast(name string, value, min int) {
+	if value < min {
+		v.add(fmt.Sprintf("--%s must be at least %d, got %d.", name, min, value), "")
+	}
+}
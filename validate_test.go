@@ -0,0 +1,175 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseOptions_validation(t *testing.T) {
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, "token")
+	os.WriteFile(tokenFile, []byte("file-token\n"), 0o600)
+	emptyTokenFile := filepath.Join(dir, "empty")
+	os.WriteFile(emptyTokenFile, []byte("\n"), 0o600)
+
+	env := map[string]string{"GITHUB_REPOSITORY": "owner/repo", "GITHUB_TOKEN": "token"}
+	// The repository is not read from the remote of the working copy the tests run in.
+	noGitDir := []string{"--git-dir", filepath.Join(dir, "missing.git")}
+	branches := []string{"--from", "main", "--to", "production"}
+
+	tests := []struct {
+		name string
+		args []string
+		env  map[string]string
+		want []string
+	}{
+		{
+			name: "missing from",
+			args: []string{"--to", "production"},
+			env:  env,
+			want: []string{"--from is required."},
+		},
+		{
+			name: "missing to",
+			args: []string{"--from", "main"},
+			env:  env,
+			want: []string{"--to is required."},
+		},
+		{
+			name: "missing repository",
+			args: append(noGitDir, branches...),
+			env:  map[string]string{"GITHUB_TOKEN": "token"},
+			want: []string{"The repository is not set."},
+		},
+		{
+			name: "invalid repository",
+			args: branches,
+			env:  map[string]string{"GITHUB_REPOSITORY": "owner", "GITHUB_TOKEN": "token"},
+			want: []string{`GITHUB_REPOSITORY must be owner/name, got "owner".`},
+		},
+		{
+			name: "invalid repo flag",
+			args: append([]string{"--repo", "owner/repo/extra"}, branches...),
+			env:  env,
+			want: []string{`--repo must be owner/name, got "owner/repo/extra".`},
+		},
+		{
+			name: "missing token",
+			args: branches,
+			env:  map[string]string{"GITHUB_REPOSITORY": "owner/repo"},
+			want: []string{"The GitHub token is not set."},
+		},
+		{
+			name: "unreadable token file",
+			args: append([]string{"--token-file", filepath.Join(dir, "missing")}, branches...),
+			env:  env,
+			want: []string{"--token-file cannot be read: open " + filepath.Join(dir, "missing") + ": no such file or directory."},
+		},
+		{
+			name: "empty token file",
+			args: append([]string{"--token-file", emptyTokenFile}, branches...),
+			env:  env,
+			want: []string{"--token-file " + emptyTokenFile + " is empty."},
+		},
+		{
+			name: "invalid api url",
+			args: branches,
+			env:  map[string]string{"GITHUB_REPOSITORY": "owner/repo", "GITHUB_TOKEN": "token", "GITHUB_API_URL": "api.github.com"},
+			want: []string{`GITHUB_API_URL "api.github.com" is not a valid URL: missing scheme or host.`},
+		},
+		{
+			name: "invalid concurrency",
+			args: append([]string{"--concurrency", "0"}, branches...),
+			env:  env,
+			want: []string{"--concurrency must be at least 1, got 0."},
+		},
+		{
+			name: "invalid exclude title pattern",
+			args: append([]string{"--exclude-title-pattern", "^(wip"}, branches...),
+			env:  env,
+			want: []string{"--exclude-title-pattern is not a valid regular expression: error parsing regexp: missing closing ): `^(wip`."},
+		},
+		{
+			name: "tracker url without pattern",
+			args: append([]string{"--tracker-url", "https://jira.example.com/browse/"}, branches...),
+			env:  env,
+			want: []string{
+				"--tracker-url is set without --tracker-key-pattern.",
+				`--tracker-url must contain {key}, got "https://jira.example.com/browse/".`,
+			},
+		},
+		{
+			name: "incomplete tracker",
+			args: append([]string{"--tracker", "jira", "--tracker-api-url", "jira.example.com"}, branches...),
+			env:  env,
+			want: []string{
+				"--tracker is set without --tracker-key-pattern.",
+				"--tracker is set without --tracker-comment or --tracker-transition.",
+				"The Jira token is not set.",
+				`--tracker-api-url "jira.example.com" is not a valid URL: missing scheme or host.`,
+			},
+		},
+		{
+			name: "every problem at once",
+			args: append(noGitDir, "--max-retries", "-1"),
+			env:  map[string]string{},
+			want: []string{
+				"--from is required.",
+				"--to is required.",
+				"The repository is not set.",
+				"The GitHub token is not set.",
+				"--max-retries must be at least 0, got -1.",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseOptions(commandCreate, tt.args, makeGetenv(tt.env))
+
+			var optionsErr *OptionsError
+			if !errors.As(err, &optionsErr) {
+				t.Fatalf("parseOptions returned error %v, want an *OptionsError", err)
+			}
+			got := []string{}
+			for _, problem := range optionsErr.Problems {
+				got = append(got, problem.message)
+			}
+			if !cmp.Equal(got, tt.want) {
+				t.Errorf("parseOptions reported %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("repo and token file", func(t *testing.T) {
+		args := append([]string{"--repo", "other/name", "--token-file", tokenFile}, branches...)
+		optionsList, err := parseOptions(commandCreate, args, makeGetenv(env))
+
+		if err != nil {
+			t.Fatalf("parseOptions returned error: %v", err)
+		}
+		options := optionsList[0]
+		if options.owner != "other" || options.repo != "name" || options.gitHubToken != "file-token" {
+			t.Errorf("parseOptions returned %v/%v with token %q, want other/name with token %q", options.owner, options.repo, options.gitHubToken, "file-token")
+		}
+	})
+}
+
+func TestOptionsError(t *testing.T) {
+	err := &OptionsError{
+		Pipeline: "staging",
+		Problems: []optionProblem{
+			{message: "--from is required.", hint: "Pass --from."},
+			{message: "--concurrency must be at least 1, got 0."},
+		},
+	}
+
+	want := "invalid options for the pipeline staging:\n  - --from is required.\n    Pass --from.\n  - --concurrency must be at least 1, got 0."
+	if err.Error() != want {
+		t.Errorf("Error returned %q, want %q", err.Error(), want)
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+)
+
+// The parts of a version that a release bumps.
+const (
+	versionBumpMajor = "major"
+	versionBumpMinor = "minor"
+	versionBumpPatch = "patch"
+)
+
+// A release version tag, e.g. "v1.2.3". Pre-release and build versions are not releases and do not match.
+var versionTagPattern = regexp.MustCompile(`^(v?)(\d+)\.(\d+)\.(\d+)$`)
+
+type semanticVersion struct {
+	// "v" or "", kept so the next version is tagged like the previous one.
+	prefix string
+	major  int
+	minor  int
+	patch  int
+}
+
+func parseVersionTag(tag string) (semanticVersion, bool) {
+	match := versionTagPattern.FindStringSubmatch(tag)
+	if match == nil {
+		return semanticVersion{}, false
+	}
+
+	numbers := [3]int{}
+	for i := 0; i < len(numbers); i++ {
+		number, err := strconv.Atoi(match[i+2])
+		if err != nil {
+			return semanticVersion{}, false
+		}
+		numbers[i] = number
+	}
+
+	return semanticVersion{prefix: match[1], major: numbers[0], minor: numbers[1], patch: numbers[2]}, true
+}
+
+func (v semanticVersion) String() string {
+	return fmt.Sprintf("%s%d.%d.%d", v.prefix, v.major, v.minor, v.patch)
+}
+
+func (v semanticVersion) bump(part string) semanticVersion {
+	switch part {
+	case versionBumpMajor:
+		return semanticVersion{prefix: v.prefix, major: v.major + 1}
+	case versionBumpMinor:
+		return semanticVersion{prefix: v.prefix, major: v.major, minor: v.minor + 1}
+	default:
+		return semanticVersion{prefix: v.prefix, major: v.major, minor: v.minor, patch: v.patch + 1}
+	}
+}
+
+func compareVersions(a, b semanticVersion) int {
+	return cmp.Or(cmp.Compare(a.major, b.major), cmp.Compare(a.minor, b.minor), cmp.Compare(a.patch, b.patch))
+}
+
+// sortVersionTags returns the release version tags of tags, the highest first.
+func sortVersionTags(tags []string) []string {
+	versions := []semanticVersion{}
+	names := map[semanticVersion]string{}
+	for i := 0; i < len(tags); i++ {
+		version, ok := parseVersionTag(tags[i])
+		if ok {
+			versions = append(versions, version)
+			names[version] = tags[i]
+		}
+	}
+
+	slices.SortStableFunc(versions, func(a, b semanticVersion) int {
+		return compareVersions(b, a)
+	})
+
+	sorted := []string{}
+	for i := 0; i < len(versions); i++ {
+		sorted = append(sorted, names[versions[i]])
+	}
+	return sorted
+}
+
+// versionBump returns the part of the version the pull requests bump:
+// major for a breaking change or one of majorLabels, minor for a feature or one of minorLabels, patch otherwise.
+func versionBump(pullRequests []TemplatePullRequest, majorLabels, minorLabels []string) string {
+	bump := versionBumpPatch
+	for i := 0; i < len(pullRequests); i++ {
+		pr := pullRequests[i]
+		if pr.Breaking || hasAnyLabel(pr, majorLabels) {
+			return versionBumpMajor
+		}
+		if pr.Type == "feat" || hasAnyLabel(pr, minorLabels) {
+			bump = versionBumpMinor
+		}
+	}
+	return bump
+}
+
+// suggestVersion returns the next version after previousTag for the pull requests.
+// Without a previous tag, the first version is bumped from v0.0.0.
+func suggestVersion(previousTag string, pullRequests []TemplatePullRequest, majorLabels, minorLabels []string) string {
+	previous, ok := parseVersionTag(previousTag)
+	if !ok {
+		previous = semanticVersion{prefix: "v"}
+	}
+	return previous.bump(versionBump(pullRequests, majorLabels, minorLabels)).String()
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v60/github"
+)
+
+func TestSortVersionTags(t *testing.T) {
+	tags := []string{"v1.2.3", "latest", "v1.10.0", "1.9.9", "v2.0.0-rc.1", "v1.2.10"}
+
+	got := sortVersionTags(tags)
+
+	want := []string{"v1.10.0", "1.9.9", "v1.2.10", "v1.2.3"}
+	if !cmp.Equal(got, want) {
+		t.Errorf("sortVersionTags returned %v, want %v", got, want)
+	}
+}
+
+func TestSuggestVersion(t *testing.T) {
+	pullRequest := func(title string, labels ...string) github.PullRequest {
+		pr := github.PullRequest{Title: github.String(title)}
+		for _, label := range labels {
+			pr.Labels = append(pr.Labels, &github.Label{Name: github.String(label)})
+		}
+		return pr
+	}
+
+	tests := []struct {
+		name         string
+		previous     string
+		pullRequests []github.PullRequest
+		want         string
+	}{
+		{
+			name:         "patch",
+			previous:     "v1.2.3",
+			pullRequests: []github.PullRequest{pullRequest("fix: typo"), pullRequest("Update README")},
+			want:         "v1.2.4",
+		},
+		{
+			name:         "minor",
+			previous:     "v1.2.3",
+			pullRequests: []github.PullRequest{pullRequest("fix: typo"), pullRequest("feat(api): add v2")},
+			want:         "v1.3.0",
+		},
+		{
+			name:         "major",
+			previous:     "1.2.3",
+			pullRequests: []github.PullRequest{pullRequest("feat: add v2"), pullRequest("refactor!: drop v1")},
+			want:         "2.0.0",
+		},
+		{
+			name:         "labels",
+			previous:     "v1.2.3",
+			pullRequests: []github.PullRequest{pullRequest("Add v2", "Enhancement")},
+			want:         "v1.3.0",
+		},
+		{
+			name:         "major label",
+			previous:     "v1.2.3",
+			pullRequests: []github.PullRequest{pullRequest("Drop v1", "breaking")},
+			want:         "v2.0.0",
+		},
+		{
+			name:         "no previous version",
+			previous:     "",
+			pullRequests: []github.PullRequest{pullRequest("feat: first")},
+			want:         "v0.1.0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := suggestVersion(tt.previous, newTemplatePullRequests(tt.pullRequests), []string{"breaking"}, []string{"enhancement"})
+			if got != tt.want {
+				t.Errorf("suggestVersion returned %v, want %v", got, tt.want)
+			}
+		})
+	}
+}